@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+// inspection is the output of the inspect command.
+type inspection struct {
+	ID        string                 `json:"id"`
+	Expired   bool                   `json:"expired"`
+	Gist      map[string]interface{} `json:"gist"`
+	Revisions int                    `json:"revisions"`
+	Stars     int64                  `json:"stars"`
+	Comments  int                    `json:"comments"`
+	Files     []inspectedFile        `json:"files"`
+}
+
+// inspectedFile is a stored file referenced by an inspected gist.
+type inspectedFile struct {
+	Name   string `json:"name"`
+	Exists bool   `json:"exists"`
+	Size   int64  `json:"size,omitempty"`
+}
+
+func runPurgeExpired(ctx context.Context, a *admin, args []string) error {
+	fs := a.newFlagSet("purge-expired", "[-dry-run]")
+	dryRun := fs.Bool("dry-run", false, "only report the gists that would be deleted")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var expired []*model.Gist
+	err := a.repo.Walk(ctx, func(g *model.Gist) error {
+		if g.Expired(now) {
+			expired = append(expired, g)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, g := range expired {
+		if *dryRun {
+			fmt.Fprintf(a.stdout, "would delete %s (expired %s)\n", g.ID, g.ExpiresAt.Format(time.RFC3339))
+			continue
+		}
+		if err := a.deleteGist(ctx, g); err != nil {
+			return fmt.Errorf("%s: %w", g.ID, err)
+		}
+		fmt.Fprintf(a.stdout, "deleted %s (expired %s)\n", g.ID, g.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return a.summary(*dryRun, "deleted", len(expired), "expired gists")
+}
+
+func runRecompute(ctx context.Context, a *admin, args []string) error {
+	fs := a.newFlagSet("recompute", "[-dry-run]")
+	dryRun := fs.Bool("dry-run", false, "only report the gists that would be updated")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	stale := make(map[string]map[string]interface{})
+	var ids []string
+	err := a.repo.WalkDocuments(ctx, func(id string, data map[string]interface{}) error {
+		if fields := model.StaleDerivedFields(data); len(fields) > 0 {
+			stale[id] = fields
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		fields := stale[id]
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if *dryRun {
+			fmt.Fprintf(a.stdout, "would update %s: %s\n", id, strings.Join(names, ", "))
+			continue
+		}
+		if err := a.repo.SetFields(ctx, id, fields); err != nil {
+			// The gist may have been deleted since it was read.
+			if isNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		fmt.Fprintf(a.stdout, "updated %s: %s\n", id, strings.Join(names, ", "))
+	}
+
+	return a.summary(*dryRun, "updated", len(ids), "gists with stale fields")
+}
+
+func runInspect(ctx context.Context, a *admin, args []string) error {
+	fs := a.newFlagSet("inspect", "<id>")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return &usageError{msg: "expected exactly one gist ID"}
+	}
+
+	g, err := a.repo.Get(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	revisions, err := a.repo.ListRevisions(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+	stars, err := a.repo.StarCount(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+	comments, err := a.repo.ListComments(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+
+	result := inspection{
+		ID:        g.ID,
+		Expired:   g.Expired(time.Now()),
+		Gist:      g.ToMap(),
+		Revisions: len(revisions),
+		Stars:     stars,
+		Comments:  len(comments),
+		Files:     []inspectedFile{},
+	}
+	for _, name := range g.StoredFiles(revisions) {
+		file, err := a.inspectFile(ctx, g.ID, name)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, file)
+	}
+
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func runDelete(ctx context.Context, a *admin, args []string) error {
+	fs := a.newFlagSet("delete", "<id>... [-dry-run]")
+	dryRun := fs.Bool("dry-run", false, "only report the gists that would be deleted")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return &usageError{msg: "expected at least one gist ID"}
+	}
+
+	for _, id := range positional {
+		g, err := a.repo.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+
+		if *dryRun {
+			fmt.Fprintf(a.stdout, "would delete %s (%s)\n", g.ID, g.Title)
+			continue
+		}
+		if err := a.deleteGist(ctx, g); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		fmt.Fprintf(a.stdout, "deleted %s (%s)\n", g.ID, g.Title)
+	}
+
+	return a.summary(*dryRun, "deleted", len(positional), "gists")
+}
+
+func runIssueToken(ctx context.Context, a *admin, args []string) error {
+	fs := a.newFlagSet("issue-token", "[-name name] <user-id>")
+	name := fs.String("name", "issued by administrator", "name shown in the user's token list")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		return &usageError{msg: "expected exactly one user ID"}
+	}
+
+	secret, err := model.NewTokenSecret()
+	if err != nil {
+		return err
+	}
+	token := model.NewAPIToken(strings.TrimSpace(positional[0]), *name, secret)
+	if _, err := a.repo.CreateToken(ctx, token); err != nil {
+		return err
+	}
+
+	// The secret is only stored hashed, so this is the one chance to
+	// hand it over.
+	_, err = fmt.Fprintln(a.stdout, secret)
+	return err
+}
+
+// deleteGist removes a gist with its revisions and stored files. Running
+// servers may keep serving it from their caches until the entries expire.
+func (a *admin) deleteGist(ctx context.Context, g *model.Gist) error {
+	revisions, err := a.repo.ListRevisions(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.repo.Delete(ctx, g.ID); err != nil {
+		return err
+	}
+
+	// The document is gone at this point, so leftover files are reported
+	// but do not stop the command; the orphans command removes them later.
+	for _, name := range g.StoredFiles(revisions) {
+		if err := a.storage.Delete(ctx, g.ID, name); err != nil {
+			fmt.Fprintf(a.stderr, "failed to delete file %s/%s: %v\n", g.ID, name, err)
+		}
+	}
+	return nil
+}
+
+// inspectFile reports whether a file of a gist is stored.
+func (a *admin) inspectFile(ctx context.Context, gistID, name string) (inspectedFile, error) {
+	reader, info, err := a.storage.Open(ctx, gistID, name)
+	if isNotFound(err) {
+		return inspectedFile{Name: name}, nil
+	}
+	if err != nil {
+		return inspectedFile{}, err
+	}
+	reader.Close()
+
+	return inspectedFile{Name: name, Exists: true, Size: info.Size}, nil
+}
+
+// summary prints the number of items a command changed or would change.
+func (a *admin) summary(dryRun bool, verb string, n int, what string) error {
+	if dryRun {
+		verb = "would have " + verb
+	}
+	_, err := fmt.Fprintf(a.stdout, "%s %d %s\n", verb, n, what)
+	return err
+}
+
+func isNotFound(err error) bool {
+	var appErr *apperror.Error
+	return errors.As(err, &appErr) && appErr.Code == apperror.CodeNotFound
+}
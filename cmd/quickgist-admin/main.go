@@ -0,0 +1,242 @@
+// Command quickgist-admin runs maintenance tasks directly against the
+// QuickGist database and file storage.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/abhisheksharm-3/quickgist/internal/config"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+	"github.com/abhisheksharm-3/quickgist/internal/storage"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Usage: quickgist-admin <command> [arguments]
+
+Commands:
+  purge-expired         delete gists whose expiry has passed
+  reconcile             delete stored files no gist refers to and report
+                        gists whose files are missing
+  recompute             recompute derived fields of old gists and fill
+                        in fields they lack, such as their visibility
+  inspect <id>          show a gist with its related records
+  delete <id>...        delete gists and their files
+  issue-token <user-id> create an API token for a user, printing its
+                        secret; users need one to create further tokens
+  migrate               copy all data to the project configured by
+                        the MIGRATE_ environment variables
+
+Every command that changes data accepts -dry-run to only report what it
+would do. The configuration is read from the environment like the server's.
+
+Run "quickgist-admin <command> -h" for the flags of a command.
+`
+
+// store is the database access the maintenance commands need.
+type store interface {
+	repository.Repository
+	repository.MaintenanceRepository
+	repository.MigrationRepository
+}
+
+// fileStore is the file storage access the maintenance commands need.
+type fileStore interface {
+	storage.FileStorage
+	storage.Lister
+}
+
+// admin carries what commands need to run.
+type admin struct {
+	repo    store
+	storage fileStore
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// command is a quickgist-admin subcommand.
+type command struct {
+	name string
+	run  func(ctx context.Context, a *admin, args []string) error
+}
+
+var commands = []command{
+	{"purge-expired", runPurgeExpired},
+	{"reconcile", runReconcile},
+	{"recompute", runRecompute},
+	{"inspect", runInspect},
+	{"delete", runDelete},
+	{"issue-token", runIssueToken},
+	{"migrate", runMigrate},
+}
+
+// usageError reports invalid command-line arguments.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "quickgist-admin: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Help needs no connections, and the flags are parsed before any
+	// command uses them.
+	a := &admin{stdout: os.Stdout, stderr: os.Stderr}
+	if !wantsHelp(args[1:]) {
+		var closeAdmin func()
+		var err error
+		a, closeAdmin, err = newAdmin(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "quickgist-admin: %v\n", err)
+			return exitError
+		}
+		defer closeAdmin()
+	}
+
+	err := cmd.run(ctx, a, args[1:])
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "quickgist-admin %s: %v\n", cmd.name, err)
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+	return exitError
+}
+
+// newAdmin connects to the database and file storage configured for the
+// server. The returned function closes the connections.
+func newAdmin(ctx context.Context) (*admin, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	firestoreClient, err := repository.NewFirestoreClient(ctx, cfg.Firebase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize firestore: %w", err)
+	}
+
+	fileStorage, err := storage.NewFirebaseStorage(
+		cfg.Firebase.CredentialsPath,
+		cfg.Firebase.StorageBucket,
+	)
+	if err != nil {
+		firestoreClient.Close()
+		return nil, nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	a := &admin{
+		repo:    repository.NewFirestoreRepository(firestoreClient),
+		storage: fileStorage,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+	}
+	return a, func() { firestoreClient.Close() }, nil
+}
+
+// newTarget connects to the database and file storage that data is
+// migrated to. The returned function closes the connections.
+func newTarget(ctx context.Context) (store, fileStore, func(), error) {
+	cfg, err := config.LoadMigrationTarget()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load migration target: %w", err)
+	}
+
+	firestoreClient, err := repository.NewFirestoreClient(ctx, cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to initialize target firestore: %w", err)
+	}
+
+	fileStorage, err := storage.NewFirebaseStorage(cfg.CredentialsPath, cfg.StorageBucket)
+	if err != nil {
+		firestoreClient.Close()
+		return nil, nil, nil, fmt.Errorf("failed to initialize target storage: %w", err)
+	}
+
+	repo := repository.NewFirestoreRepository(firestoreClient)
+	return repo, fileStorage, func() { firestoreClient.Close() }, nil
+}
+
+// newFlagSet creates the flag set of a command, printing its usage line
+// and flags on -h.
+func (a *admin) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: quickgist-admin %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// wantsHelp reports whether the arguments of a command ask for its usage.
+func wantsHelp(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if arg == "-h" || arg == "-help" || arg == "--help" || arg == "--h" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments, which it returns.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
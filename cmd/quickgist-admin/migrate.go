@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/abhisheksharm-3/quickgist/internal/migrate"
+)
+
+const (
+	defaultCheckpoint       = "quickgist-migrate.checkpoint"
+	defaultMigrateWorkers   = 8
+	defaultVerifySampleSize = 100
+)
+
+func runMigrate(ctx context.Context, a *admin, args []string) error {
+	fs := a.newFlagSet("migrate", "[-checkpoint file] [-workers n] [-verify [-samples n]] [-dry-run]")
+	checkpointPath := fs.String("checkpoint", defaultCheckpoint, "file recording the progress, used to resume an interrupted migration")
+	workers := fs.Int("workers", defaultMigrateWorkers, "number of gists copied in parallel")
+	verify := fs.Bool("verify", false, "compare the record counts and a sample of gists instead of copying")
+	samples := fs.Int("samples", defaultVerifySampleSize, "number of gists compared by -verify")
+	dryRun := fs.Bool("dry-run", false, "only report how many gists would be copied")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *workers < 1 || *samples < 0 {
+		return &usageError{msg: "-workers must be positive and -samples must not be negative"}
+	}
+
+	target, targetFiles, closeTarget, err := newTarget(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeTarget()
+
+	infoLog := log.New(a.stderr, "", log.LstdFlags)
+
+	if *verify {
+		migrator := migrate.New(a.repo, a.storage, target, targetFiles, nil, *workers, infoLog)
+		return a.verifyMigration(ctx, migrator, *samples)
+	}
+
+	checkpoint, err := migrate.OpenCheckpoint(*checkpointPath)
+	if err != nil {
+		return err
+	}
+	defer checkpoint.Close()
+
+	migrator := migrate.New(a.repo, a.storage, target, targetFiles, checkpoint, *workers, infoLog)
+
+	if *dryRun {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(a.stdout, "would copy %d gists with their revisions, comments, stars and files, and all API tokens, webhooks and exports\n", pending)
+		return err
+	}
+
+	stats, err := migrator.Run(ctx)
+	fmt.Fprintf(a.stdout, "copied %d gists (%d copied before), %d revisions, %d comments, %d stars, %d files (%d bytes)\n",
+		stats.Gists, stats.SkippedGists, stats.Revisions, stats.Comments, stats.Stars, stats.Files, stats.Bytes)
+	fmt.Fprintf(a.stdout, "copied %d API tokens, %d webhooks, %d webhook deliveries and %d exports\n",
+		stats.Tokens, stats.Webhooks, stats.Deliveries, stats.Exports)
+	if stats.MissingFiles > 0 {
+		fmt.Fprintf(a.stdout, "%d files were missing from the source and not copied\n", stats.MissingFiles)
+	}
+	if err != nil {
+		return fmt.Errorf("%w; run the command again to resume", err)
+	}
+	return nil
+}
+
+// verifyMigration prints how the migration target compares to the source,
+// failing if they differ.
+func (a *admin) verifyMigration(ctx context.Context, migrator *migrate.Migrator, samples int) error {
+	report, err := migrator.Verify(ctx, samples)
+	if err != nil {
+		return err
+	}
+
+	rows := []struct {
+		name        string
+		source, dst int64
+	}{
+		{"gists", report.Source.Gists, report.Destination.Gists},
+		{"revisions", report.Source.Revisions, report.Destination.Revisions},
+		{"comments", report.Source.Comments, report.Destination.Comments},
+		{"stars", report.Source.Stars, report.Destination.Stars},
+		{"tokens", report.Source.Tokens, report.Destination.Tokens},
+		{"webhooks", report.Source.Webhooks, report.Destination.Webhooks},
+		{"deliveries", report.Source.Deliveries, report.Destination.Deliveries},
+		{"exports", report.Source.Exports, report.Destination.Exports},
+	}
+	fmt.Fprintf(a.stdout, "%-10s %10s %12s\n", "", "source", "destination")
+	for _, row := range rows {
+		fmt.Fprintf(a.stdout, "%-10s %10d %12d\n", row.name, row.source, row.dst)
+	}
+
+	fmt.Fprintf(a.stdout, "compared %d sampled gists\n", report.Sampled)
+	for _, mismatch := range report.Mismatches {
+		fmt.Fprintln(a.stdout, mismatch)
+	}
+
+	if !report.OK() {
+		return errors.New("the destination differs from the source")
+	}
+	return nil
+}
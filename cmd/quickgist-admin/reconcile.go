@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/storage"
+)
+
+const (
+	// exportStorageID, exportFileSuffix and stagingStorageID mirror where
+	// the API stores data exports and attachments of gists being created.
+	exportStorageID  = "_exports"
+	exportFileSuffix = ".zip"
+	stagingStorageID = "_staging"
+
+	defaultReconcileMinAge = time.Hour
+)
+
+// orphan is a stored file that nothing refers to.
+type orphan struct {
+	gistID string
+	info   *storage.FileInfo
+	reason string
+}
+
+// missingFile is a file a gist refers to that is not stored.
+type missingFile struct {
+	gistID string
+	name   string
+}
+
+func runReconcile(ctx context.Context, a *admin, args []string) error {
+	fs := a.newFlagSet("reconcile", "[-min-age duration] [-dry-run]")
+	minAge := fs.Duration("min-age", defaultReconcileMinAge, "ignore files and gists modified more recently, which may still be being written")
+	dryRun := fs.Bool("dry-run", false, "only report the files that would be deleted")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	orphans, missing, err := a.reconcile(ctx, time.Now().Add(-*minAge))
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, o := range orphans {
+		size += o.info.Size
+		if *dryRun {
+			fmt.Fprintf(a.stdout, "would delete %s/%s (%d bytes): %s\n", o.gistID, o.info.FileName, o.info.Size, o.reason)
+			continue
+		}
+		if err := a.storage.Delete(ctx, o.gistID, o.info.FileName); err != nil {
+			return fmt.Errorf("%s/%s: %w", o.gistID, o.info.FileName, err)
+		}
+		fmt.Fprintf(a.stdout, "deleted %s/%s (%d bytes): %s\n", o.gistID, o.info.FileName, o.info.Size, o.reason)
+	}
+
+	for _, m := range missing {
+		fmt.Fprintf(a.stdout, "missing %s/%s: attachment of gist %s is not stored\n", m.gistID, m.name, m.gistID)
+	}
+
+	if err := a.summary(*dryRun, "deleted", len(orphans), fmt.Sprintf("orphaned files, %d bytes", size)); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(a.stdout, "found %d gists with missing attachments\n", len(missing))
+	return err
+}
+
+// reconcile compares the stored files with the gists and exports that
+// refer to them. It returns the files nothing refers to and the
+// attachments that are not stored, ignoring files and gists modified after
+// cutoff since they may still be being written.
+func (a *admin) reconcile(ctx context.Context, cutoff time.Time) ([]orphan, []missingFile, error) {
+	stored := make(map[string]map[string]*storage.FileInfo)
+	err := a.storage.Walk(ctx, func(gistID string, info *storage.FileInfo) error {
+		if stored[gistID] == nil {
+			stored[gistID] = make(map[string]*storage.FileInfo)
+		}
+		stored[gistID][info.FileName] = info
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var orphans []orphan
+	var missing []missingFile
+	addOrphan := func(gistID string, info *storage.FileInfo, reason string) {
+		if info.Updated.Before(cutoff) {
+			orphans = append(orphans, orphan{gistID: gistID, info: info, reason: reason})
+		}
+	}
+
+	exports := stored[exportStorageID]
+	delete(stored, exportStorageID)
+	for name, info := range exports {
+		_, err := a.repo.GetExport(ctx, strings.TrimSuffix(name, exportFileSuffix))
+		if isNotFound(err) {
+			addOrphan(exportStorageID, info, "export does not exist")
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Staged attachments are moved away once their gist is created, so
+	// any that are left belong to creations that failed midway.
+	for _, info := range stored[stagingStorageID] {
+		addOrphan(stagingStorageID, info, "staged upload was never committed")
+	}
+	delete(stored, stagingStorageID)
+
+	err = a.repo.Walk(ctx, func(g *model.Gist) error {
+		files := stored[g.ID]
+		delete(stored, g.ID)
+		if modifiedAt(g).After(cutoff) {
+			return nil
+		}
+
+		if g.FileName != "" && files[g.FileName] == nil {
+			missing = append(missing, missingFile{gistID: g.ID, name: g.FileName})
+		}
+
+		// Revisions are only loaded for gists with other files, which are
+		// usually stored copies of revision attachments.
+		var others []*storage.FileInfo
+		for name, info := range files {
+			if name != g.FileName {
+				others = append(others, info)
+			}
+		}
+		if len(others) == 0 {
+			return nil
+		}
+
+		revisions, err := a.repo.ListRevisions(ctx, g.ID)
+		if err != nil {
+			return err
+		}
+		referenced := make(map[string]bool)
+		for _, name := range g.StoredFiles(revisions) {
+			referenced[name] = true
+		}
+		for _, info := range others {
+			if !referenced[info.FileName] {
+				addOrphan(g.ID, info, "not referenced by the gist")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for gistID, files := range stored {
+		for _, info := range files {
+			addOrphan(gistID, info, "gist does not exist")
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].gistID != orphans[j].gistID {
+			return orphans[i].gistID < orphans[j].gistID
+		}
+		return orphans[i].info.FileName < orphans[j].info.FileName
+	})
+	sort.Slice(missing, func(i, j int) bool { return missing[i].gistID < missing[j].gistID })
+	return orphans, missing, nil
+}
+
+// modifiedAt returns when a gist was last written.
+func modifiedAt(g *model.Gist) time.Time {
+	if g.UpdatedAt.After(g.CreatedAt) {
+		return g.UpdatedAt
+	}
+	return g.CreatedAt
+}
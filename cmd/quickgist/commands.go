@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/abhisheksharm-3/quickgist/internal/client"
+	"github.com/abhisheksharm-3/quickgist/internal/language"
+)
+
+const (
+	defaultListLimit = 30
+	stdinTitle       = "Untitled"
+)
+
+func runLogin(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("login", "[-server URL] [-token TOKEN]")
+	server := fs.String("server", a.config.Server, "API server URL")
+	token := fs.String("token", "", "API token; read from standard input when omitted")
+	if _, err := parseArgs(fs, args, nil); err != nil {
+		return err
+	}
+
+	if *token == "" {
+		fmt.Fprint(a.stderr, "API token: ")
+		line, err := bufio.NewReader(a.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		*token = strings.TrimSpace(line)
+	}
+	if *token == "" {
+		return &usageError{msg: "an API token is required"}
+	}
+
+	// The token is checked before it is stored.
+	cfg := &config{Server: strings.TrimRight(*server, "/"), Token: *token}
+	if _, err := client.New(cfg.Server, cfg.Token).List(ctx, client.ListOptions{Limit: 1}); err != nil {
+		return err
+	}
+
+	path, err := saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Saved credentials for %s to %s\n", cfg.Server, path)
+	return nil
+}
+
+func runCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("create", "<file|-> [flags]")
+	var req client.CreateRequest
+	fs.StringVar(&req.Title, "t", "", "title (default: the file name)")
+	fs.StringVar(&req.Title, "title", "", "title (default: the file name)")
+	fs.StringVar(&req.Description, "d", "", "description")
+	fs.StringVar(&req.Description, "description", "", "description")
+	fs.StringVar(&req.Language, "l", "", "language (default: detected)")
+	fs.StringVar(&req.Language, "language", "", "language (default: detected)")
+	tags := fs.String("tags", "", "comma-separated tags")
+	private := fs.Bool("private", false, "only visible to you")
+	unlisted := fs.Bool("unlisted", false, "only visible with the link")
+	fs.BoolVar(&req.IsDraft, "draft", false, "save as a draft")
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args, output)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return &usageError{msg: "expected one file, or - for standard input"}
+	}
+	if *private && *unlisted {
+		return &usageError{msg: "-private and -unlisted are mutually exclusive"}
+	}
+
+	path := positional[0]
+	content, err := readInput(a, path)
+	if err != nil {
+		return err
+	}
+	req.Content = content
+
+	if req.Title == "" {
+		req.Title = stdinTitle
+		if path != "-" {
+			req.Title = filepath.Base(path)
+		}
+	}
+	if req.Language == "" && path != "-" {
+		req.Language = language.Detect(filepath.Base(path), content)
+	}
+	switch {
+	case *private:
+		req.Visibility = "private"
+	case *unlisted:
+		req.Visibility = "unlisted"
+	}
+	if *tags != "" {
+		req.Tags = strings.Split(*tags, ",")
+	}
+
+	gist, err := a.client.Create(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return a.printJSON(gist)
+	}
+	_, err = fmt.Fprintln(a.stdout, gist.ID)
+	return err
+}
+
+func runGet(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("get", "<id> [-raw] [-o table|json]")
+	raw := fs.Bool("raw", false, "print only the content")
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args, output)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return &usageError{msg: "expected a gist ID"}
+	}
+
+	gist, err := a.client.Get(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *raw:
+		_, err = io.WriteString(a.stdout, withNewline(gist.Content))
+		return err
+	case *output == outputJSON:
+		return a.printJSON(gist)
+	default:
+		return a.printGist(gist)
+	}
+}
+
+func runList(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("ls", "[flags]")
+	var opts client.ListOptions
+	fs.IntVar(&opts.Limit, "limit", defaultListLimit, "gists per page")
+	fs.StringVar(&opts.Cursor, "cursor", "", "cursor of the page to list")
+	fs.StringVar(&opts.Tag, "tag", "", "only gists with this tag")
+	fs.StringVar(&opts.Language, "language", "", "only gists in this language")
+	all := fs.Bool("all", false, "list every page")
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args, output)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return &usageError{msg: "ls takes no arguments"}
+	}
+	if a.config.Token == "" {
+		return &usageError{msg: `not logged in; run "quickgist login"`}
+	}
+
+	var gists []client.Gist
+	for {
+		page, err := a.client.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		gists = append(gists, page.Gists...)
+		opts.Cursor = page.NextCursor
+
+		if !*all || opts.Cursor == "" {
+			break
+		}
+	}
+
+	if *output == outputJSON {
+		return a.printJSON(client.GistList{Gists: gists, NextCursor: opts.Cursor})
+	}
+	if err := a.printGistTable(gists); err != nil {
+		return err
+	}
+	if opts.Cursor != "" {
+		fmt.Fprintf(a.stderr, "\nMore gists: quickgist ls -cursor %s\n", opts.Cursor)
+	}
+	return nil
+}
+
+func runRemove(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("rm", "<id>... [-o table|json]")
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args, output)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return &usageError{msg: "expected at least one gist ID"}
+	}
+
+	for _, id := range positional {
+		if err := a.client.Delete(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+
+		if *output == outputJSON {
+			err = a.printJSON(map[string]interface{}{"snippetId": id, "deleted": true})
+		} else {
+			_, err = fmt.Fprintf(a.stdout, "Deleted %s\n", id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runEdit(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("edit", "<id> [-o table|json]")
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args, output)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return &usageError{msg: "expected a gist ID"}
+	}
+
+	gist, err := a.client.Get(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	content, err := editContent(a, gist)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(content) == strings.TrimSpace(gist.Content) {
+		fmt.Fprintln(a.stderr, "No changes")
+		return nil
+	}
+
+	// The update fails rather than overwrite edits made in the meantime.
+	gist, err = a.client.Update(ctx, gist.ID, client.UpdateRequest{Content: &content, IfMatch: gist.ETag})
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return a.printJSON(gist)
+	}
+	_, err = fmt.Fprintf(a.stdout, "Updated %s\n", gist.ID)
+	return err
+}
+
+// editContent opens the content of a gist in the user's editor and returns
+// the saved content.
+func editContent(a *app, gist *client.Gist) (string, error) {
+	f, err := os.CreateTemp("", "quickgist-*"+language.Extension(gist.Language))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(withNewline(gist.Content))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	editor := strings.Fields(editorCommand())
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running editor: %w", err)
+	}
+
+	content, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// editorCommand returns the user's editor, which may include arguments.
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// readInput reads the file at path, or standard input for "-".
+func readInput(a *app, path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(a.stdin)
+		return string(data), err
+	}
+
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+func withNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
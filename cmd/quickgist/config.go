@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultServer = "http://localhost:8000"
+
+// config is the stored configuration of the client. QUICKGIST_SERVER and
+// QUICKGIST_TOKEN override the stored values.
+type config struct {
+	Server string `json:"server"`
+	Token  string `json:"token,omitempty"`
+}
+
+// configPath returns the location of the configuration file, which
+// QUICKGIST_CONFIG overrides.
+func configPath() (string, error) {
+	if path := os.Getenv("QUICKGIST_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "quickgist", "config.json"), nil
+}
+
+func loadConfig() (*config, error) {
+	cfg := &config{}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+
+	if v := os.Getenv("QUICKGIST_SERVER"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("QUICKGIST_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+	cfg.Server = strings.TrimRight(cfg.Server, "/")
+
+	return cfg, nil
+}
+
+// saveConfig writes the configuration readable only by the user, since it
+// holds the API token.
+func saveConfig(cfg *config) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
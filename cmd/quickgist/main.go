@@ -0,0 +1,162 @@
+// Command quickgist creates and manages gists from the command line.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/client"
+)
+
+// Exit codes. API errors map to a code by their apperror code so that
+// scripts can tell failures apart.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitNotFound  = 3
+	exitAuth      = 4
+	exitInvalid   = 5
+	exitConflict  = 6
+	exitRateLimit = 7
+	exitServer    = 8
+)
+
+const usage = `Usage: quickgist <command> [arguments]
+
+Commands:
+  login                 store the server URL and API token
+  create <file|->       create a gist from a file or standard input
+  get <id>              show a gist
+  ls                    list your gists
+  rm <id>...            delete gists
+  edit <id>             edit a gist in $EDITOR
+  sync <file> -gist <id>
+                        push every change of a file to a gist
+
+Run "quickgist <command> -h" for the flags of a command.
+
+Exit codes: 0 success, 1 error, 2 usage, 3 not found, 4 unauthorized or
+forbidden, 5 invalid request, 6 conflict, 7 rate limited, 8 server error.
+`
+
+// app carries what commands need to run.
+type app struct {
+	config *config
+	client *client.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is a quickgist subcommand.
+type command struct {
+	name string
+	run  func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = []command{
+	{"login", runLogin},
+	{"create", runCreate},
+	{"get", runGet},
+	{"ls", runList},
+	{"rm", runRemove},
+	{"edit", runEdit},
+	{"sync", runSync},
+}
+
+// usageError reports invalid command-line arguments.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "quickgist: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "quickgist: %v\n", err)
+		return exitError
+	}
+
+	a := &app{
+		config: cfg,
+		client: client.New(cfg.Server, cfg.Token),
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = cmd.run(ctx, a, args[1:])
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "quickgist %s: %v\n", cmd.name, err)
+	return exitCode(err)
+}
+
+// exitCode maps an error to the exit status of the command.
+func exitCode(err error) int {
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		return exitError
+	}
+
+	switch appErr.Code {
+	case apperror.CodeNotFound:
+		return exitNotFound
+	case apperror.CodeUnauthorized, apperror.CodeForbidden:
+		return exitAuth
+	case apperror.CodeBadRequest, apperror.CodeValidation:
+		return exitInvalid
+	case apperror.CodeConflict, apperror.CodePrecondition:
+		return exitConflict
+	case apperror.CodeRateLimit:
+		return exitRateLimit
+	case apperror.CodeInternal, apperror.CodeStorageError, apperror.CodeDatabaseError, apperror.CodeNotImplemented:
+		return exitServer
+	default:
+		return exitError
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/client"
+)
+
+// Output formats selected with -o.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+const maxTitleWidth = 48
+
+// newFlagSet creates the flag set of a command, printing its usage line
+// and flags on -h.
+func (a *app) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: quickgist %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// outputFlag registers the -o flag on a command.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("o", outputTable, "output format: table or json")
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments, which it returns. Arguments after "--" are always
+// positional.
+func parseArgs(fs *flag.FlagSet, args []string, output *string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	if output != nil && *output != outputTable && *output != outputJSON {
+		return nil, &usageError{msg: fmt.Sprintf("unknown output format %q", *output)}
+	}
+	return positional, nil
+}
+
+func (a *app) printJSON(v interface{}) error {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printGist prints the details of a gist followed by its content.
+func (a *app) printGist(g *client.Gist) error {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+
+	rows := [][2]string{
+		{"ID", g.ID},
+		{"Title", g.Title},
+		{"Description", g.Description},
+		{"Language", g.Language},
+		{"Visibility", visibilityLabel(g)},
+		{"Tags", strings.Join(g.Tags, ", ")},
+		{"Attachment", g.FileName},
+		{"Created", formatTime(g.CreatedAt)},
+	}
+	if g.UpdatedAt != nil {
+		rows = append(rows, [2]string{"Updated", formatTime(*g.UpdatedAt)})
+	}
+
+	for _, row := range rows {
+		if row[1] != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(a.stdout, "\n%s\n", strings.TrimRight(g.Content, "\n"))
+	return err
+}
+
+// printGistTable prints a listing with one gist per row.
+func (a *app) printGistTable(gists []client.Gist) error {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tLANGUAGE\tVISIBILITY\tCREATED")
+	for _, g := range gists {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			g.ID, truncate(g.Title, maxTitleWidth), g.Language, visibilityLabel(&g), formatTime(g.CreatedAt))
+	}
+	return tw.Flush()
+}
+
+func visibilityLabel(g *client.Gist) string {
+	if g.IsDraft {
+		return g.Visibility + " (draft)"
+	}
+	return g.Visibility
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/client"
+)
+
+const (
+	defaultDebounce = time.Second
+
+	// maxDebounceDelays bounds how long a file that keeps changing, such as
+	// a log being appended to, goes without being pushed, in multiples of
+	// the debounce interval.
+	maxDebounceDelays = 10
+)
+
+// syncer pushes the content of a local file to a gist. Every update is
+// conditional on the version it last pushed or saw, so that edits made
+// elsewhere are never overwritten.
+type syncer struct {
+	app    *app
+	path   string
+	gistID string
+	etag   string
+	pushed string
+}
+
+func runSync(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("sync", "<file> -gist <id> [-debounce duration]")
+	gistID := fs.String("gist", "", "ID of the gist to update")
+	debounce := fs.Duration("debounce", defaultDebounce, "quiet period after a change before it is pushed")
+
+	positional, err := parseArgs(fs, args, nil)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return &usageError{msg: "expected a file to sync"}
+	}
+	if *gistID == "" {
+		return &usageError{msg: "-gist is required"}
+	}
+	if *debounce <= 0 {
+		return &usageError{msg: "-debounce must be positive"}
+	}
+
+	path, err := filepath.Abs(positional[0])
+	if err != nil {
+		return err
+	}
+
+	s := &syncer{app: a, path: path, gistID: *gistID}
+	if err := s.start(ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Syncing %s to gist %s; press Ctrl+C to stop\n", path, *gistID)
+	return s.watch(ctx, *debounce)
+}
+
+// start brings the gist up to date with the file. A gist that changed
+// after the file was last written is not overwritten.
+func (s *syncer) start(ctx context.Context) error {
+	gist, err := s.app.client.Get(ctx, s.gistID)
+	if err != nil {
+		return err
+	}
+	s.etag = gist.ETag
+	s.pushed = gist.Content
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	if sameContent(string(content), gist.Content) {
+		return nil
+	}
+
+	remoteModified := gist.CreatedAt
+	if gist.UpdatedAt != nil {
+		remoteModified = *gist.UpdatedAt
+	}
+	if remoteModified.After(info.ModTime()) {
+		return apperror.Conflict(fmt.Sprintf(
+			"gist %s was changed after %s; fetch it with \"quickgist get %s -raw\" before syncing",
+			s.gistID, filepath.Base(s.path), s.gistID))
+	}
+
+	return s.push(ctx, string(content))
+}
+
+// watch pushes the file after every burst of changes until ctx is done.
+func (s *syncer) watch(ctx context.Context, debounce time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchFile(ctx, s.path, changes)
+	}()
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	var pendingSince time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-watchErr:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("watching %s: %w", s.path, err)
+
+		case <-changes:
+			now := time.Now()
+			if pendingSince.IsZero() {
+				pendingSince = now
+			}
+			wait := debounce
+			if limit := pendingSince.Add(maxDebounceDelays * debounce).Sub(now); limit < wait {
+				wait = max(limit, 0)
+			}
+			timer.Reset(wait)
+
+		case <-timer.C:
+			pendingSince = time.Time{}
+
+			content, err := os.ReadFile(s.path)
+			if errors.Is(err, fs.ErrNotExist) {
+				// Editors that save by renaming briefly remove the file;
+				// its replacement triggers another change.
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if sameContent(string(content), s.pushed) {
+				continue
+			}
+
+			if err := s.push(ctx, string(content)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// push updates the gist unless it changed since the last known version.
+func (s *syncer) push(ctx context.Context, content string) error {
+	if strings.TrimSpace(content) == "" {
+		fmt.Fprintf(s.app.stderr, "%s is empty; not syncing it\n", filepath.Base(s.path))
+		return nil
+	}
+
+	gist, err := s.app.client.Update(ctx, s.gistID, client.UpdateRequest{Content: &content, IfMatch: s.etag})
+	if apperror.Is(err, apperror.CodePrecondition) {
+		return apperror.Conflict(fmt.Sprintf(
+			"gist %s was changed elsewhere; stopped syncing to keep those edits", s.gistID))
+	}
+	if err != nil {
+		return err
+	}
+
+	s.etag = gist.ETag
+	s.pushed = gist.Content
+	fmt.Fprintf(s.app.stderr, "%s synced %d bytes\n", time.Now().Format("15:04:05"), len(gist.Content))
+	return nil
+}
+
+// signalChange signals a change without blocking; a pending signal already
+// covers it.
+func signalChange(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+// sameContent compares contents the way the server stores them, without
+// surrounding whitespace.
+func sameContent(a, b string) bool {
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// watchEvents are the inotify events that may change a watched file. The
+// directory is watched rather than the file so that saves that replace the
+// file are seen too.
+const watchEvents = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_CREATE |
+	syscall.IN_MOVED_TO | syscall.IN_DELETE | syscall.IN_MOVED_FROM
+
+// watchFile reports possible changes of the file at path on changes until
+// ctx is done, using inotify.
+func watchFile(ctx context.Context, path string, changes chan<- struct{}) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+	// A non-blocking descriptor is handled by the runtime poller, so
+	// closing the file interrupts a pending read.
+	f := os.NewFile(uintptr(fd), "inotify")
+	defer f.Close()
+
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), watchEvents); err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	name := filepath.Base(path)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + syscall.SizeofInotifyEvent
+			offset = start + int(event.Len)
+
+			if strings.TrimRight(string(buf[start:offset]), "\x00") == name {
+				signalChange(changes)
+			}
+		}
+	}
+}
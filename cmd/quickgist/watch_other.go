@@ -0,0 +1,44 @@
+//go:build !linux
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+const pollInterval = 500 * time.Millisecond
+
+// watchFile reports possible changes of the file at path on changes until
+// ctx is done, by polling its size and modification time.
+func watchFile(ctx context.Context, path string, changes chan<- struct{}) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var last os.FileInfo
+	if info, err := os.Stat(path); err == nil {
+		last = info
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if last != nil {
+				last = nil
+				signalChange(changes)
+			}
+			continue
+		}
+		if last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+			last = info
+			signalChange(changes)
+		}
+	}
+}
@@ -4,16 +4,12 @@ import (
 	"context"
 	"log"
 	"os"
-	"time"
-
-	"cloud.google.com/go/firestore"
-	firebase "firebase.google.com/go"
-	"google.golang.org/api/option"
 
 	"github.com/abhisheksharm-3/quickgist/internal/cache"
 	"github.com/abhisheksharm-3/quickgist/internal/config"
 	"github.com/abhisheksharm-3/quickgist/internal/handler"
 	"github.com/abhisheksharm-3/quickgist/internal/repository"
+	"github.com/abhisheksharm-3/quickgist/internal/search"
 	"github.com/abhisheksharm-3/quickgist/internal/server"
 	"github.com/abhisheksharm-3/quickgist/internal/storage"
 )
@@ -27,7 +23,7 @@ func main() {
 		errorLog.Fatalf("failed to load config: %v", err)
 	}
 
-	firestoreClient, err := initFirestore(cfg.Firebase)
+	firestoreClient, err := repository.NewFirestoreClient(context.Background(), cfg.Firebase)
 	if err != nil {
 		errorLog.Fatalf("failed to initialize firestore: %v", err)
 	}
@@ -43,8 +39,29 @@ func main() {
 
 	repo := repository.NewFirestoreRepository(firestoreClient)
 	gistCache := cache.NewMemoryCache(1000)
+	searchIndex := search.NewMemoryIndex()
 
-	h := handler.New(repo, fileStorage, gistCache, infoLog, errorLog)
+	go func() {
+		n, err := repo.BackfillVisibility(context.Background())
+		if err != nil {
+			errorLog.Printf("failed to backfill gist visibility after %d gists: %v", n, err)
+			return
+		}
+		if n > 0 {
+			infoLog.Printf("backfilled the visibility of %d gists", n)
+		}
+	}()
+
+	go func() {
+		n, err := search.Rebuild(context.Background(), searchIndex, repo)
+		if err != nil {
+			errorLog.Printf("failed to build search index after %d gists: %v", n, err)
+			return
+		}
+		infoLog.Printf("indexed %d gists for search", n)
+	}()
+
+	h := handler.New(cfg, repo, fileStorage, gistCache, searchIndex, infoLog, errorLog)
 
 	srv := server.New(cfg, h, infoLog, errorLog)
 
@@ -52,28 +69,3 @@ func main() {
 		errorLog.Fatalf("server error: %v", err)
 	}
 }
-
-func initFirestore(cfg config.FirebaseConfig) (*firestore.Client, error) {
-	ctx := context.Background()
-
-	opt := option.WithCredentialsFile(cfg.CredentialsPath)
-	fbConfig := &firebase.Config{ProjectID: cfg.ProjectID}
-
-	app, err := firebase.NewApp(ctx, fbConfig, opt)
-	if err != nil {
-		return nil, err
-	}
-
-	var client *firestore.Client
-	for i := 0; i < cfg.MaxRetries; i++ {
-		client, err = app.Firestore(ctx)
-		if err == nil {
-			return client, nil
-		}
-		if i < cfg.MaxRetries-1 {
-			time.Sleep(time.Second * time.Duration(i+1))
-		}
-	}
-
-	return nil, err
-}
@@ -1,12 +1,13 @@
 import { api } from './api-client';
-import type { GistType, CreateGistParamsType } from '@/types/gist-types';
+import type { GistType, GistListType, GistSummaryType, CreateGistParamsType } from '@/types/gist-types';
 
 export async function fetchGist(id: string): Promise<GistType> {
     return api.get<GistType>(`/gist/view/${id}`);
 }
 
-export async function fetchUserGists(userId: string): Promise<GistType[]> {
-    return api.get<GistType[]>('/gist/user-gists', { userId });
+export async function fetchUserGists(userId: string): Promise<GistSummaryType[]> {
+    const page = await api.get<GistListType>('/gist/user-gists', { userId });
+    return page.gists;
 }
 
 export async function createGist(params: CreateGistParamsType): Promise<GistType> {
@@ -25,7 +25,7 @@ const FAQ_ITEMS = [
     },
     {
         question: 'What file types are supported?',
-        answer: 'You can share any text-based code or upload files up to 10MB. We support syntax highlighting for most programming languages.',
+        answer: 'You can share any text-based code or upload files up to 50MB. We support syntax highlighting for most programming languages.',
     },
     {
         question: 'Can I edit or delete my gists?',
@@ -100,7 +100,7 @@ export function ViewGistPage() {
                 {gist.content && (
                     <div className="border-2 border-border overflow-hidden mb-6">
                         <SyntaxHighlighter
-                            language="javascript"
+                            language={gist.language || 'text'}
                             style={oneDark}
                             customStyle={{
                                 margin: 0,
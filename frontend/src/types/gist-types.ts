@@ -3,13 +3,39 @@ export type GistType = {
     title: string;
     description: string;
     content: string;
+    language?: string;
+    tags?: string[];
     isDraft: boolean;
     createdAt: string;
+    updatedAt?: string;
+    stars?: number;
+    starred?: boolean;
+    viewCount?: number;
+    downloadCount?: number;
     userId?: string;
     fileName?: string;
     fileURL?: string;
 };
 
+export type GistSummaryType = {
+    snippetId: string;
+    title: string;
+    description: string;
+    excerpt: string;
+    size: number;
+    language: string;
+    tags?: string[];
+    isDraft: boolean;
+    visibility: string;
+    createdAt: string;
+    fileName?: string;
+};
+
+export type GistListType = {
+    gists: GistSummaryType[];
+    nextCursor?: string;
+};
+
 export type CreateGistParamsType = {
     title: string;
     description: string;
@@ -27,15 +27,18 @@ func (e *Error) Unwrap() error {
 
 // Standard error codes
 const (
-	CodeNotFound      = "NOT_FOUND"
-	CodeBadRequest    = "BAD_REQUEST"
-	CodeUnauthorized  = "UNAUTHORIZED"
-	CodeForbidden     = "FORBIDDEN"
-	CodeInternal      = "INTERNAL_ERROR"
-	CodeValidation    = "VALIDATION_ERROR"
-	CodeRateLimit     = "RATE_LIMIT_EXCEEDED"
-	CodeStorageError  = "STORAGE_ERROR"
-	CodeDatabaseError = "DATABASE_ERROR"
+	CodeNotFound       = "NOT_FOUND"
+	CodeBadRequest     = "BAD_REQUEST"
+	CodeUnauthorized   = "UNAUTHORIZED"
+	CodeForbidden      = "FORBIDDEN"
+	CodeConflict       = "CONFLICT"
+	CodePrecondition   = "PRECONDITION_FAILED"
+	CodeInternal       = "INTERNAL_ERROR"
+	CodeValidation     = "VALIDATION_ERROR"
+	CodeRateLimit      = "RATE_LIMIT_EXCEEDED"
+	CodeStorageError   = "STORAGE_ERROR"
+	CodeDatabaseError  = "DATABASE_ERROR"
+	CodeNotImplemented = "NOT_IMPLEMENTED"
 )
 
 // NotFound creates a not found error.
@@ -74,6 +77,25 @@ func Forbidden(message string) *Error {
 	}
 }
 
+// Conflict creates an error for a write that lost a race with another one.
+func Conflict(message string) *Error {
+	return &Error{
+		Code:    CodeConflict,
+		Message: message,
+		Status:  http.StatusConflict,
+	}
+}
+
+// PreconditionFailed creates an error for a conditional write whose
+// precondition no longer holds.
+func PreconditionFailed(message string) *Error {
+	return &Error{
+		Code:    CodePrecondition,
+		Message: message,
+		Status:  http.StatusPreconditionFailed,
+	}
+}
+
 // Internal creates an internal server error.
 func Internal(err error) *Error {
 	return &Error{
@@ -122,6 +144,15 @@ func Database(err error) *Error {
 	}
 }
 
+// NotImplemented creates a not implemented error.
+func NotImplemented(message string) *Error {
+	return &Error{
+		Code:    CodeNotImplemented,
+		Message: message,
+		Status:  http.StatusNotImplemented,
+	}
+}
+
 // Wrap wraps an error with additional context.
 func Wrap(err error, message string) *Error {
 	var appErr *Error
@@ -0,0 +1,234 @@
+// Package client is a small client of the QuickGist API used by the
+// command-line tools.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+)
+
+const (
+	requestTimeout  = 30 * time.Second
+	maxErrorBodyLen = 64 << 10
+)
+
+// Client calls the QuickGist API, authenticating with an API token.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New creates a client for the API at baseURL. An empty token makes
+// anonymous requests.
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Create creates a gist.
+func (c *Client) Create(ctx context.Context, req CreateRequest) (*Gist, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	fields := [][2]string{
+		{"title", req.Title},
+		{"description", req.Description},
+		{"content", req.Content},
+		{"language", req.Language},
+		{"visibility", req.Visibility},
+		{"tags", strings.Join(req.Tags, ",")},
+	}
+	if req.IsDraft {
+		fields = append(fields, [2]string{"isDraft", "true"})
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	r, err := c.newRequest(ctx, http.MethodPost, "/gist/create", &body)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return c.doGist(r)
+}
+
+// Get retrieves a gist.
+func (c *Client) Get(ctx context.Context, id string) (*Gist, error) {
+	r, err := c.newRequest(ctx, http.MethodGet, "/gist/view/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.doGist(r)
+}
+
+// List retrieves a page of the token owner's gists.
+func (c *Client) List(ctx context.Context, opts ListOptions) (*GistList, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	if opts.Tag != "" {
+		query.Set("tag", opts.Tag)
+	}
+	if opts.Language != "" {
+		query.Set("language", opts.Language)
+	}
+
+	r, err := c.newRequest(ctx, http.MethodGet, "/gist/user-gists?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list GistList
+	if _, err := c.do(r, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Update changes the fields of a gist set in req.
+func (c *Client) Update(ctx context.Context, id string, req UpdateRequest) (*Gist, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.newRequest(ctx, http.MethodPut, "/gist/"+url.PathEscape(id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if req.IfMatch != "" {
+		r.Header.Set("If-Match", req.IfMatch)
+	}
+
+	return c.doGist(r)
+}
+
+// Delete deletes a gist.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	r, err := c.newRequest(ctx, http.MethodDelete, "/gist/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(r, nil)
+	return err
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		r.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return r, nil
+}
+
+// doGist sends a request that responds with a gist, recording its ETag.
+func (c *Client) doGist(r *http.Request) (*Gist, error) {
+	var gist Gist
+	header, err := c.do(r, &gist)
+	if err != nil {
+		return nil, err
+	}
+	gist.ETag = header.Get("ETag")
+	return &gist, nil
+}
+
+// do sends a request, decodes its JSON response into out and returns the
+// response headers. Error responses are returned as *apperror.Error
+// carrying the API error code.
+func (c *Client) do(r *http.Request, out interface{}) (http.Header, error) {
+	resp, err := c.http.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, responseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// responseError converts an error response into an *apperror.Error. Bodies
+// that are not API errors, such as those of proxies, get a code derived
+// from the status.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen))
+
+	var e errorResponse
+	if err := json.Unmarshal(body, &e); err != nil || e.Code == "" {
+		e.Code = statusCode(resp.StatusCode)
+		e.Message = strings.TrimSpace(string(body))
+		if e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+
+	return &apperror.Error{
+		Code:    e.Code,
+		Message: e.Message,
+		Status:  resp.StatusCode,
+	}
+}
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return apperror.CodeNotFound
+	case http.StatusBadRequest:
+		return apperror.CodeBadRequest
+	case http.StatusUnauthorized:
+		return apperror.CodeUnauthorized
+	case http.StatusForbidden:
+		return apperror.CodeForbidden
+	case http.StatusConflict:
+		return apperror.CodeConflict
+	case http.StatusPreconditionFailed:
+		return apperror.CodePrecondition
+	case http.StatusTooManyRequests:
+		return apperror.CodeRateLimit
+	case http.StatusNotImplemented:
+		return apperror.CodeNotImplemented
+	default:
+		return apperror.CodeInternal
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import "time"
+
+// Gist is a gist as returned by the API. Listings only fill in the summary
+// fields.
+type Gist struct {
+	ID            string     `json:"snippetId"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Content       string     `json:"content"`
+	Language      string     `json:"language"`
+	Tags          []string   `json:"tags,omitempty"`
+	IsDraft       bool       `json:"isDraft"`
+	Visibility    string     `json:"visibility"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+	Stars         int64      `json:"stars"`
+	ViewCount     int64      `json:"viewCount"`
+	DownloadCount int64      `json:"downloadCount"`
+	UserID        string     `json:"userId,omitempty"`
+	FileName      string     `json:"fileName,omitempty"`
+	FileURL       string     `json:"fileURL,omitempty"`
+	FileSHA256    string     `json:"fileSha256,omitempty"`
+
+	// ETag identifies the version of the gist for conditional updates.
+	ETag string `json:"-"`
+}
+
+// GistList is a page of gists.
+type GistList struct {
+	Gists      []Gist `json:"gists"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ListOptions controls pagination and filtering of List.
+type ListOptions struct {
+	Limit    int
+	Cursor   string
+	Tag      string
+	Language string
+}
+
+// CreateRequest describes a new gist. Empty fields take the server
+// defaults.
+type CreateRequest struct {
+	Title       string
+	Description string
+	Content     string
+	Language    string
+	Visibility  string
+	IsDraft     bool
+	Tags        []string
+}
+
+// UpdateRequest changes the fields of a gist that are set. With IfMatch
+// set to an ETag the update fails with a precondition error when the gist
+// changed since that version.
+type UpdateRequest struct {
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Content     *string   `json:"content,omitempty"`
+	Language    *string   `json:"language,omitempty"`
+	IsDraft     *bool     `json:"isDraft,omitempty"`
+	Visibility  *string   `json:"visibility,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+
+	IfMatch string `json:"-"`
+}
+
+// errorResponse is the body of API error responses.
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
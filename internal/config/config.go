@@ -16,6 +16,8 @@ type Config struct {
 	Firebase  FirebaseConfig
 	CORS      CORSConfig
 	RateLimit RateLimitConfig
+	Stats     StatsConfig
+	Webhook   WebhookConfig
 }
 
 // Load reads configuration from environment variables.
@@ -29,7 +31,17 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	firebaseCfg, err := loadFirebaseConfig()
+	firebaseCfg, err := loadFirebaseConfig("")
+	if err != nil {
+		return nil, err
+	}
+
+	statsCfg, err := loadStatsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	webhookCfg, err := loadWebhookConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +51,8 @@ func Load() (*Config, error) {
 		Firebase:  firebaseCfg,
 		CORS:      loadCORSConfig(),
 		RateLimit: loadRateLimitConfig(),
+		Stats:     statsCfg,
+		Webhook:   webhookCfg,
 	}, nil
 }
 
@@ -51,6 +65,8 @@ func loadServerConfig() (ServerConfig, error) {
 	return ServerConfig{
 		Port:            port,
 		Env:             getEnv("APP_ENV", "development"),
+		PublicURL:       strings.TrimRight(os.Getenv("PUBLIC_URL"), "/"),
+		AppURL:          strings.TrimRight(getEnv("APP_URL", "https://quickgist.vercel.app"), "/"),
 		ReadTimeout:     getDuration("READ_TIMEOUT", 10*time.Second),
 		WriteTimeout:    getDuration("WRITE_TIMEOUT", 30*time.Second),
 		IdleTimeout:     getDuration("IDLE_TIMEOUT", 120*time.Second),
@@ -59,22 +75,29 @@ func loadServerConfig() (ServerConfig, error) {
 	}, nil
 }
 
-func loadFirebaseConfig() (FirebaseConfig, error) {
-	credPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+// LoadMigrationTarget loads the Firebase project that data is migrated
+// to, configured like the server's own project with every variable
+// prefixed by MIGRATE_.
+func LoadMigrationTarget() (FirebaseConfig, error) {
+	return loadFirebaseConfig("MIGRATE_")
+}
+
+func loadFirebaseConfig(prefix string) (FirebaseConfig, error) {
+	credPath := os.Getenv(prefix + "GOOGLE_APPLICATION_CREDENTIALS")
 	if credPath == "" {
-		return FirebaseConfig{}, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS not set")
+		return FirebaseConfig{}, fmt.Errorf("%sGOOGLE_APPLICATION_CREDENTIALS not set", prefix)
 	}
 
-	projectID := os.Getenv("FIREBASE_PROJECT_ID")
+	projectID := os.Getenv(prefix + "FIREBASE_PROJECT_ID")
 	if projectID == "" {
-		return FirebaseConfig{}, fmt.Errorf("FIREBASE_PROJECT_ID not set")
+		return FirebaseConfig{}, fmt.Errorf("%sFIREBASE_PROJECT_ID not set", prefix)
 	}
 
 	return FirebaseConfig{
 		CredentialsPath: credPath,
 		ProjectID:       projectID,
-		StorageBucket:   getEnv("FIREBASE_STORAGE_BUCKET", projectID+".appspot.com"),
-		MaxRetries:      getInt("FIRESTORE_MAX_RETRIES", 3),
+		StorageBucket:   getEnv(prefix+"FIREBASE_STORAGE_BUCKET", projectID+".appspot.com"),
+		MaxRetries:      getInt(prefix+"FIRESTORE_MAX_RETRIES", 3),
 	}, nil
 }
 
@@ -101,6 +124,36 @@ func loadRateLimitConfig() RateLimitConfig {
 	}
 }
 
+func loadStatsConfig() (StatsConfig, error) {
+	flushInterval, err := getPositiveDuration("STATS_FLUSH_INTERVAL", 30*time.Second)
+	if err != nil {
+		return StatsConfig{}, err
+	}
+
+	return StatsConfig{
+		FlushInterval: flushInterval,
+	}, nil
+}
+
+func loadWebhookConfig() (WebhookConfig, error) {
+	pollInterval, err := getPositiveDuration("WEBHOOK_POLL_INTERVAL", 10*time.Second)
+	if err != nil {
+		return WebhookConfig{}, err
+	}
+
+	timeout, err := getPositiveDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return WebhookConfig{}, err
+	}
+
+	return WebhookConfig{
+		PollInterval:        pollInterval,
+		Timeout:             timeout,
+		MaxAttempts:         getInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		AllowPrivateTargets: getEnv("WEBHOOK_ALLOW_PRIVATE_TARGETS", "false") == "true",
+	}, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -117,6 +170,16 @@ func getDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getPositiveDuration is getDuration for intervals and timeouts that must
+// be positive, such as ticker periods, which panic on anything else.
+func getPositiveDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	d := getDuration(key, defaultValue)
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %s", key, d)
+	}
+	return d, nil
+}
+
 func getInt(key string, defaultValue int) int {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.Atoi(v); err == nil {
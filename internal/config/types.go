@@ -6,6 +6,8 @@ import "time"
 type ServerConfig struct {
 	Port            string
 	Env             string
+	PublicURL       string
+	AppURL          string
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
@@ -36,6 +38,19 @@ type RateLimitConfig struct {
 	Enabled           bool
 }
 
+// StatsConfig holds usage counter configuration.
+type StatsConfig struct {
+	FlushInterval time.Duration
+}
+
+// WebhookConfig holds outgoing webhook delivery configuration.
+type WebhookConfig struct {
+	PollInterval        time.Duration
+	Timeout             time.Duration
+	MaxAttempts         int
+	AllowPrivateTargets bool
+}
+
 // IsDevelopment returns true if running in development mode.
 func (c *ServerConfig) IsDevelopment() bool {
 	return c.Env == "development"
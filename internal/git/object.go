@@ -0,0 +1,212 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileMode is the mode of every file in a synthesized tree.
+const fileMode = "100644"
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{objects: make(map[Hash]*Object)}
+}
+
+// Add stores an object and returns its hash. Adding an existing object is
+// a no-op.
+func (s *Store) Add(t ObjectType, data []byte) Hash {
+	h := HashObject(t, data)
+	if _, ok := s.objects[h]; !ok {
+		s.objects[h] = &Object{Type: t, Data: data}
+		s.order = append(s.order, h)
+	}
+	return h
+}
+
+// Get returns a stored object.
+func (s *Store) Get(h Hash) (*Object, bool) {
+	obj, ok := s.objects[h]
+	return obj, ok
+}
+
+// Len returns the number of stored objects.
+func (s *Store) Len() int {
+	return len(s.order)
+}
+
+// HashObject computes the ID of an object.
+func HashObject(t ObjectType, data []byte) Hash {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%s %d\x00", t, len(data))
+	hash.Write(data)
+
+	var h Hash
+	copy(h[:], hash.Sum(nil))
+	return h
+}
+
+// String returns the name Git uses for the object type.
+func (t ObjectType) String() string {
+	switch t {
+	case ObjectCommit:
+		return "commit"
+	case ObjectTree:
+		return "tree"
+	case ObjectBlob:
+		return "blob"
+	case ObjectTag:
+		return "tag"
+	}
+	return "unknown"
+}
+
+// BuildHistory stores one commit per snapshot, oldest first, each with the
+// previous one as parent, and returns the hash of the last commit. The
+// same snapshots always produce the same hashes.
+func BuildHistory(store *Store, snapshots []Snapshot) Hash {
+	var head Hash
+	for _, snap := range snapshots {
+		tree := store.addTree(snap.Files)
+		if snap.Commit != nil {
+			head = store.Add(ObjectCommit, snap.Commit)
+			continue
+		}
+		head = store.addCommit(tree, head, snap)
+	}
+	return head
+}
+
+// TreeHash returns the ID of the tree holding the given files.
+func TreeHash(files []File) Hash {
+	return NewStore().addTree(files)
+}
+
+// Commit returns a stored commit.
+func (s *Store) Commit(h Hash) (*Commit, error) {
+	data, err := s.data(h, ObjectCommit)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCommit(data)
+}
+
+// Tree returns the entries of a stored tree.
+func (s *Store) Tree(h Hash) ([]TreeEntry, error) {
+	data, err := s.data(h, ObjectTree)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTree(data)
+}
+
+// Blob returns the content of a stored blob.
+func (s *Store) Blob(h Hash) ([]byte, error) {
+	return s.data(h, ObjectBlob)
+}
+
+func (s *Store) data(h Hash, t ObjectType) ([]byte, error) {
+	obj, ok := s.objects[h]
+	if !ok {
+		return nil, fmt.Errorf("missing object %s", h)
+	}
+	if obj.Type != t {
+		return nil, fmt.Errorf("object %s is a %s, not a %s", h, obj.Type, t)
+	}
+	return obj.Data, nil
+}
+
+// ParseCommit parses the data of a commit object.
+func ParseCommit(data []byte) (*Commit, error) {
+	header, message, _ := bytes.Cut(data, []byte("\n\n"))
+
+	c := &Commit{Message: string(message)}
+	hasTree := false
+	for _, line := range strings.Split(string(header), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			h, err := ParseHash(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Tree = h
+			hasTree = true
+		case "parent":
+			h, err := ParseHash(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Parents = append(c.Parents, h)
+		}
+	}
+
+	if !hasTree {
+		return nil, errors.New("commit has no tree")
+	}
+	return c, nil
+}
+
+// ParseTree parses the data of a tree object.
+func ParseTree(data []byte) ([]TreeEntry, error) {
+	var entries []TreeEntry
+	for len(data) > 0 {
+		mode, rest, ok := bytes.Cut(data, []byte(" "))
+		if !ok {
+			return nil, errors.New("malformed tree entry")
+		}
+		name, rest, ok := bytes.Cut(rest, []byte{0})
+		if !ok || len(rest) < len(Hash{}) {
+			return nil, errors.New("malformed tree entry")
+		}
+
+		entry := TreeEntry{Mode: string(mode), Name: string(name)}
+		copy(entry.Hash[:], rest)
+		entries = append(entries, entry)
+
+		data = rest[len(Hash{}):]
+	}
+	return entries, nil
+}
+
+func (s *Store) addTree(files []File) Hash {
+	sorted := make([]File, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var buf bytes.Buffer
+	for _, f := range sorted {
+		blob := s.Add(ObjectBlob, f.Content)
+		buf.WriteString(fileMode + " " + f.Name + "\x00")
+		buf.Write(blob[:])
+	}
+
+	return s.Add(ObjectTree, buf.Bytes())
+}
+
+func (s *Store) addCommit(tree, parent Hash, snap Snapshot) Hash {
+	var buf bytes.Buffer
+	buf.WriteString("tree " + tree.String() + "\n")
+	if !parent.IsZero() {
+		buf.WriteString("parent " + parent.String() + "\n")
+	}
+
+	sig := formatSignature(snap.Author)
+	buf.WriteString("author " + sig + "\n")
+	buf.WriteString("committer " + sig + "\n")
+	buf.WriteString("\n" + snap.Message)
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	return s.Add(ObjectCommit, buf.Bytes())
+}
+
+func formatSignature(sig Signature) string {
+	return sig.Name + " <" + sig.Email + "> " + strconv.FormatInt(sig.When.Unix(), 10) + " +0000"
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+)
+
+// WritePack writes every object of the store as an undeltified version 2
+// pack file.
+func WritePack(w io.Writer, store *Store) error {
+	hash := sha1.New()
+	out := io.MultiWriter(w, hash)
+
+	header := make([]byte, 12)
+	copy(header, "PACK")
+	binary.BigEndian.PutUint32(header[4:], 2)
+	binary.BigEndian.PutUint32(header[8:], uint32(store.Len()))
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, h := range store.order {
+		obj := store.objects[h]
+		if _, err := out.Write(objectHeader(obj.Type, len(obj.Data))); err != nil {
+			return err
+		}
+
+		zw := zlib.NewWriter(out)
+		if _, err := zw.Write(obj.Data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(hash.Sum(nil))
+	return err
+}
+
+// objectHeader encodes the type and size of a packed object: the type in
+// bits 4-6 of the first byte, followed by the size as a little-endian
+// base-128 varint.
+func objectHeader(t ObjectType, size int) []byte {
+	b := byte(t)<<4 | byte(size&0x0f)
+	size >>= 4
+
+	var header []byte
+	for size > 0 {
+		header = append(header, b|0x80)
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	return append(header, b)
+}
@@ -0,0 +1,325 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Pack object types that only occur in pack files.
+const (
+	objectOfsDelta = 6
+	objectRefDelta = 7
+)
+
+// minPackedObjectSize is the fewest bytes an object takes up in a pack: a
+// one-byte header and the smallest zlib stream.
+const minPackedObjectSize = 9
+
+// errPackTooLarge reports a pack exceeding its PackLimits.
+var errPackTooLarge = errors.New("pack exceeds size limits")
+
+// packReader tracks the offset and checksum of the pack being read. It
+// implements io.ByteReader so that zlib does not read past the end of each
+// object.
+type packReader struct {
+	r      *bufio.Reader
+	hash   hash.Hash
+	offset int64
+}
+
+func (p *packReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.hash.Write(b[:n])
+	p.offset += int64(n)
+	return n, err
+}
+
+func (p *packReader) ReadByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err == nil {
+		p.hash.Write([]byte{b})
+		p.offset++
+	}
+	return b, err
+}
+
+// packedDelta is a delta whose base has not been resolved yet.
+type packedDelta struct {
+	offset  int64
+	baseOfs int64
+	baseRef Hash
+	data    []byte
+}
+
+// ReadPack reads a pack file into the store, failing once the pack
+// exceeds limits. Deltas may use objects that are already stored as their
+// base. A missing pack, as sent by pushes that only move refs to existing
+// commits, is not an error.
+func ReadPack(r *bufio.Reader, store *Store, limits PackLimits) error {
+	if _, err := r.Peek(1); err == io.EOF {
+		return nil
+	}
+
+	p := &packReader{r: r, hash: sha1.New()}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(p, header); err != nil {
+		return fmt.Errorf("reading pack header: %w", err)
+	}
+	if string(header[:4]) != "PACK" {
+		return errors.New("invalid pack signature")
+	}
+	if v := binary.BigEndian.Uint32(header[4:]); v != 2 && v != 3 {
+		return fmt.Errorf("unsupported pack version %d", v)
+	}
+	count := binary.BigEndian.Uint32(header[8:])
+
+	// The count is only a claim of the client, so it must fit both the
+	// limit and the bytes the pack may take before it sizes anything.
+	if int64(count) > int64(limits.MaxObjects) || int64(count)*minPackedObjectSize > limits.MaxPackSize {
+		return fmt.Errorf("%w: %d objects", errPackTooLarge, count)
+	}
+
+	byOffset := make(map[int64]Hash, count)
+	var deltas []packedDelta
+	budget := limits.MaxTotalSize
+
+	for i := uint32(0); i < count; i++ {
+		offset := p.offset
+		t, size, err := readObjectHeader(p)
+		if err != nil {
+			return err
+		}
+
+		delta := packedDelta{offset: offset}
+		switch t {
+		case ObjectCommit, ObjectTree, ObjectBlob, ObjectTag:
+		case objectOfsDelta:
+			rel, err := readOffset(p)
+			if err != nil {
+				return err
+			}
+			delta.baseOfs = offset - rel
+		case objectRefDelta:
+			if _, err := io.ReadFull(p, delta.baseRef[:]); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid object type %d", t)
+		}
+
+		if size > limits.MaxObjectSize || size > budget {
+			return fmt.Errorf("%w: object of %d bytes", errPackTooLarge, size)
+		}
+		budget -= size
+
+		data, err := inflate(p, size)
+		if err != nil {
+			return err
+		}
+
+		if t == objectOfsDelta || t == objectRefDelta {
+			delta.data = data
+			deltas = append(deltas, delta)
+			continue
+		}
+		byOffset[offset] = store.Add(t, data)
+	}
+
+	var trailer Hash
+	sum := p.hash.Sum(nil)
+	if _, err := io.ReadFull(r, trailer[:]); err != nil {
+		return fmt.Errorf("reading pack checksum: %w", err)
+	}
+	if !bytes.Equal(trailer[:], sum) {
+		return errors.New("pack checksum mismatch")
+	}
+
+	return resolveDeltas(store, byOffset, deltas, limits.MaxObjectSize, budget)
+}
+
+// resolveDeltas applies deltas until every one has its base, which may
+// itself be a delta later in the pack. Each result may hold at most
+// maxSize bytes, and all of them together at most budget bytes.
+func resolveDeltas(store *Store, byOffset map[int64]Hash, deltas []packedDelta, maxSize, budget int64) error {
+	for len(deltas) > 0 {
+		var pending []packedDelta
+		for _, d := range deltas {
+			base, ok := d.baseRef, true
+			if d.baseRef.IsZero() {
+				base, ok = byOffset[d.baseOfs]
+			}
+
+			obj, found := store.Get(base)
+			if !ok || !found {
+				pending = append(pending, d)
+				continue
+			}
+
+			data, err := applyDelta(obj.Data, d.data, min(maxSize, budget))
+			if err != nil {
+				return err
+			}
+			budget -= int64(len(data))
+			byOffset[d.offset] = store.Add(obj.Type, data)
+		}
+
+		if len(pending) == len(deltas) {
+			return fmt.Errorf("%d deltas have missing bases", len(pending))
+		}
+		deltas = pending
+	}
+	return nil
+}
+
+func readObjectHeader(p *packReader) (ObjectType, int64, error) {
+	b, err := p.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	t := ObjectType(b >> 4 & 0x07)
+	size := int64(b & 0x0f)
+	shift := uint(4)
+	for b&0x80 != 0 {
+		if b, err = p.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= int64(b&0x7f) << shift
+		shift += 7
+		if shift > 56 {
+			return 0, 0, errors.New("object size overflows")
+		}
+	}
+	return t, size, nil
+}
+
+// readOffset reads the base offset of an ofs-delta, which is encoded
+// big-endian with an implicit +1 per continuation byte.
+func readOffset(p *packReader) (int64, error) {
+	b, err := p.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	offset := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		if b, err = p.ReadByte(); err != nil {
+			return 0, err
+		}
+		offset = (offset+1)<<7 | int64(b&0x7f)
+		if offset > 1<<48 {
+			return 0, errors.New("delta offset overflows")
+		}
+	}
+	return offset, nil
+}
+
+func inflate(p *packReader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(p)
+	if err != nil {
+		return nil, fmt.Errorf("inflating object: %w", err)
+	}
+	defer zr.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(zr, size+1)); err != nil {
+		return nil, fmt.Errorf("inflating object: %w", err)
+	}
+	if int64(buf.Len()) != size {
+		return nil, errors.New("object size mismatch")
+	}
+
+	// Reading to the end consumes the zlib checksum.
+	if _, err := io.Copy(io.Discard, zr); err != nil {
+		return nil, fmt.Errorf("inflating object: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// applyDelta rebuilds an object of at most maxSize bytes from its base and
+// a delta, which starts with both sizes and is followed by copy and insert
+// instructions.
+func applyDelta(base, delta []byte, maxSize int64) ([]byte, error) {
+	baseSize, delta, err := deltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != len(base) {
+		return nil, errors.New("delta base size mismatch")
+	}
+
+	resultSize, delta, err := deltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+	if int64(resultSize) > maxSize {
+		return nil, fmt.Errorf("%w: delta result of %d bytes", errPackTooLarge, resultSize)
+	}
+
+	result := make([]byte, 0, resultSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			n := int(op)
+			if n == 0 || n > len(delta) {
+				return nil, errors.New("invalid delta insert")
+			}
+			result = append(result, delta[:n]...)
+			delta = delta[n:]
+			continue
+		}
+
+		var offset, size int
+		for i := 0; i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, errors.New("truncated delta copy")
+			}
+			if i < 4 {
+				offset |= int(delta[0]) << (8 * i)
+			} else {
+				size |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, errors.New("delta copy out of range")
+		}
+		result = append(result, base[offset:offset+size]...)
+	}
+
+	if len(result) != resultSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+	return result, nil
+}
+
+func deltaSize(delta []byte) (int, []byte, error) {
+	size, shift := 0, uint(0)
+	for i, b := range delta {
+		size |= int(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return size, delta[i+1:], nil
+		}
+		if shift > 35 {
+			break
+		}
+	}
+	return 0, nil, errors.New("invalid delta header")
+}
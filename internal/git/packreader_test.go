@@ -0,0 +1,245 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testLimits leaves enough room for every valid pack in these tests.
+var testLimits = PackLimits{
+	MaxPackSize:   1 << 20,
+	MaxObjects:    100,
+	MaxObjectSize: 1 << 16,
+	MaxTotalSize:  1 << 18,
+}
+
+func TestReadPack(t *testing.T) {
+	base := []byte("hello, world\n")
+	baseHash := HashObject(ObjectBlob, base)
+	// Copies "hello, " from the base and inserts "gopher\n".
+	delta := append([]byte{byte(len(base)), 14, 0x91, 0, 7, 7}, "gopher\n"...)
+	result := []byte("hello, gopher\n")
+
+	history := NewStore()
+	BuildHistory(history, []Snapshot{{
+		Files:   []File{{Name: "a.txt", Content: []byte("a")}, {Name: "b.txt", Content: []byte("b")}},
+		Message: "Initial commit",
+		Author:  Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)},
+	}})
+	var written bytes.Buffer
+	if err := WritePack(&written, history); err != nil {
+		t.Fatalf("WritePack: %v", err)
+	}
+
+	ofsEntry := packEntry(ObjectBlob, base)
+
+	tests := []struct {
+		name   string
+		pack   []byte
+		stored [][]byte
+		want   [][]byte
+		// objects holds further objects the pack must have added.
+		objects *Store
+	}{
+		{
+			name: "no pack",
+			pack: nil,
+		},
+		{
+			name:    "written pack",
+			pack:    written.Bytes(),
+			objects: history,
+		},
+		{
+			name: "ofs delta",
+			pack: buildPack(ofsEntry, deltaEntry(objectOfsDelta, []byte{byte(len(ofsEntry))}, delta)),
+			want: [][]byte{base, result},
+		},
+		{
+			name: "ref delta",
+			pack: buildPack(packEntry(ObjectBlob, base), deltaEntry(objectRefDelta, baseHash[:], delta)),
+			want: [][]byte{base, result},
+		},
+		{
+			name: "ref delta before its base",
+			pack: buildPack(deltaEntry(objectRefDelta, baseHash[:], delta), packEntry(ObjectBlob, base)),
+			want: [][]byte{base, result},
+		},
+		{
+			name:   "ref delta on a stored object",
+			pack:   buildPack(deltaEntry(objectRefDelta, baseHash[:], delta)),
+			stored: [][]byte{base},
+			want:   [][]byte{result},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore()
+			for _, data := range tt.stored {
+				store.Add(ObjectBlob, data)
+			}
+
+			if err := ReadPack(bufio.NewReader(bytes.NewReader(tt.pack)), store, testLimits); err != nil {
+				t.Fatalf("ReadPack: %v", err)
+			}
+			for _, data := range tt.want {
+				obj, ok := store.Get(HashObject(ObjectBlob, data))
+				if !ok || !bytes.Equal(obj.Data, data) {
+					t.Errorf("store is missing blob %q", data)
+				}
+			}
+			if tt.objects != nil {
+				for _, h := range tt.objects.order {
+					if _, ok := store.Get(h); !ok {
+						t.Errorf("store is missing object %s", h)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReadPackLimits(t *testing.T) {
+	blob := bytes.Repeat([]byte("x"), 100)
+	// Copies the whole 100 byte base three times.
+	delta := []byte{100, 0xac, 0x02, 0x91, 0, 100, 0x91, 0, 100, 0x91, 0, 100}
+
+	tests := []struct {
+		name   string
+		pack   []byte
+		limits PackLimits
+	}{
+		{
+			name:   "too many objects",
+			pack:   buildPack(packEntry(ObjectBlob, blob), packEntry(ObjectBlob, blob)),
+			limits: PackLimits{MaxPackSize: 1 << 20, MaxObjects: 1, MaxObjectSize: 1 << 10, MaxTotalSize: 1 << 10},
+		},
+		{
+			name:   "count larger than the pack can hold",
+			pack:   packHeader(1 << 20),
+			limits: PackLimits{MaxPackSize: 1 << 20, MaxObjects: 1 << 30, MaxObjectSize: 1 << 10, MaxTotalSize: 1 << 10},
+		},
+		{
+			name:   "object too large",
+			pack:   buildPack(packEntry(ObjectBlob, blob)),
+			limits: PackLimits{MaxPackSize: 1 << 20, MaxObjects: 10, MaxObjectSize: 99, MaxTotalSize: 1 << 10},
+		},
+		{
+			name:   "declared size too large",
+			pack:   append(packHeader(1), objectHeader(ObjectBlob, 1<<40)...),
+			limits: testLimits,
+		},
+		{
+			name:   "total size too large",
+			pack:   buildPack(packEntry(ObjectBlob, blob), packEntry(ObjectBlob, append(blob, 'y'))),
+			limits: PackLimits{MaxPackSize: 1 << 20, MaxObjects: 10, MaxObjectSize: 1 << 10, MaxTotalSize: 150},
+		},
+		{
+			name:   "delta result too large",
+			pack:   buildPack(packEntry(ObjectBlob, blob), deltaEntry(objectOfsDelta, []byte{byte(len(packEntry(ObjectBlob, blob)))}, delta)),
+			limits: PackLimits{MaxPackSize: 1 << 20, MaxObjects: 10, MaxObjectSize: 200, MaxTotalSize: 1 << 10},
+		},
+		{
+			name:   "delta results over the total size",
+			pack:   buildPack(packEntry(ObjectBlob, blob), deltaEntry(objectOfsDelta, []byte{byte(len(packEntry(ObjectBlob, blob)))}, delta)),
+			limits: PackLimits{MaxPackSize: 1 << 20, MaxObjects: 10, MaxObjectSize: 1 << 10, MaxTotalSize: 200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ReadPack(bufio.NewReader(bytes.NewReader(tt.pack)), NewStore(), tt.limits)
+			if !errors.Is(err, errPackTooLarge) {
+				t.Errorf("error = %v, want %v", err, errPackTooLarge)
+			}
+		})
+	}
+}
+
+func TestReadPackMalformed(t *testing.T) {
+	blob := []byte("hello, world\n")
+	valid := buildPack(packEntry(ObjectBlob, blob))
+
+	corrupt := bytes.Clone(valid)
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	badVersion := bytes.Clone(valid)
+	binary.BigEndian.PutUint32(badVersion[4:], 4)
+
+	var missing Hash
+	missing[0] = 1
+
+	tests := []struct {
+		name string
+		pack []byte
+	}{
+		{"short header", []byte("PACK")},
+		{"invalid signature", append([]byte("KCAP"), valid[4:]...)},
+		{"unsupported version", badVersion},
+		{"missing objects", packHeader(2)},
+		{"missing checksum", valid[:len(valid)-sha1.Size]},
+		{"checksum mismatch", corrupt},
+		{"invalid object type", buildPack(append(objectHeader(5, len(blob)), deflate(blob)...))},
+		{"size mismatch", buildPack(append(objectHeader(ObjectBlob, len(blob)+1), deflate(blob)...))},
+		{"not zlib", buildPack(append(objectHeader(ObjectBlob, len(blob)), blob...))},
+		{"size overflows", buildPack(bytes.Repeat([]byte{0xff}, 10))},
+		{"missing delta base", buildPack(deltaEntry(objectRefDelta, missing[:], []byte{1, 1, 1, 'x'}))},
+		{"delta base size mismatch", buildPack(packEntry(ObjectBlob, blob), deltaEntry(objectOfsDelta, []byte{byte(len(packEntry(ObjectBlob, blob)))}, []byte{1, 1, 1, 'x'}))},
+		{"delta copy out of range", buildPack(packEntry(ObjectBlob, blob), deltaEntry(objectOfsDelta, []byte{byte(len(packEntry(ObjectBlob, blob)))}, []byte{byte(len(blob)), 20, 0x91, 0, 20}))},
+		{"empty delta insert", buildPack(packEntry(ObjectBlob, blob), deltaEntry(objectOfsDelta, []byte{byte(len(packEntry(ObjectBlob, blob)))}, []byte{byte(len(blob)), 1, 0}))},
+		{"delta result size mismatch", buildPack(packEntry(ObjectBlob, blob), deltaEntry(objectOfsDelta, []byte{byte(len(packEntry(ObjectBlob, blob)))}, []byte{byte(len(blob)), 5, 1, 'x'}))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ReadPack(bufio.NewReader(bytes.NewReader(tt.pack)), NewStore(), testLimits); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// packHeader returns the header of a version 2 pack holding count objects.
+func packHeader(count uint32) []byte {
+	header := make([]byte, 12)
+	copy(header, "PACK")
+	binary.BigEndian.PutUint32(header[4:], 2)
+	binary.BigEndian.PutUint32(header[8:], count)
+	return header
+}
+
+// buildPack joins packed entries into a pack with a valid checksum.
+func buildPack(entries ...[]byte) []byte {
+	pack := packHeader(uint32(len(entries)))
+	for _, e := range entries {
+		pack = append(pack, e...)
+	}
+	sum := sha1.Sum(pack)
+	return append(pack, sum[:]...)
+}
+
+func packEntry(t ObjectType, data []byte) []byte {
+	return append(objectHeader(t, len(data)), deflate(data)...)
+}
+
+// deltaEntry packs a delta whose base is given by base, the encoded offset
+// of an ofs-delta or the hash of a ref-delta.
+func deltaEntry(t ObjectType, base, delta []byte) []byte {
+	entry := append(objectHeader(t, len(delta)), base...)
+	return append(entry, deflate(delta)...)
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	maxPktLen = 65520
+
+	// maxSidebandData is the payload of a side-band-64k packet, leaving room
+	// for the length prefix and the band byte.
+	maxSidebandData = maxPktLen - 5
+
+	bandData     = 1
+	bandProgress = 2
+	bandError    = 3
+)
+
+var errFlush = errors.New("flush packet")
+
+// pktLine formats data as a pkt-line.
+func pktLine(data string) []byte {
+	return []byte(fmt.Sprintf("%04x%s", len(data)+4, data))
+}
+
+// flushPkt is the pkt-line that ends a section.
+var flushPkt = []byte("0000")
+
+// readPktLine reads one pkt-line, returning errFlush for a flush packet.
+func readPktLine(r *bufio.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pkt-line length %q", size)
+	}
+	if n == 0 {
+		return nil, errFlush
+	}
+	if n < 4 || n > maxPktLen {
+		return nil, fmt.Errorf("invalid pkt-line length %d", n)
+	}
+
+	data := make([]byte, n-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// sidebandWriter splits everything written to it into side-band packets on
+// one band.
+type sidebandWriter struct {
+	w    io.Writer
+	band byte
+}
+
+func (s *sidebandWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxSidebandData {
+			chunk = chunk[:maxSidebandData]
+		}
+
+		header := fmt.Sprintf("%04x", len(chunk)+5)
+		if _, err := io.WriteString(s.w, header); err != nil {
+			return written, err
+		}
+		if _, err := s.w.Write([]byte{s.band}); err != nil {
+			return written, err
+		}
+		if _, err := s.w.Write(chunk); err != nil {
+			return written, err
+		}
+
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
@@ -0,0 +1,150 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const receivePackCapabilities = "report-status side-band-64k quiet ofs-delta no-thin " + agent
+
+// Errors returned by FastForward.
+var (
+	ErrNonFastForward = errors.New("non-fast-forward")
+	ErrMergeCommit    = errors.New("merge commits are not supported")
+	ErrTooManyCommits = errors.New("too many commits")
+)
+
+// AdvertiseReceivePack writes the receive-pack ref advertisement.
+func AdvertiseReceivePack(w io.Writer, head Hash) error {
+	return AdvertiseRefs(w, ReceivePack, head, receivePackCapabilities)
+}
+
+// ReadPushCommands reads the ref updates that start a receive-pack request.
+// The pack follows and can be read with ReadPack. A request without
+// commands is a probe and yields ErrProbe.
+func ReadPushCommands(r *bufio.Reader) (*PushRequest, error) {
+	req := &PushRequest{}
+
+	for {
+		line, err := readPktLine(r)
+		if err == errFlush {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		text := strings.TrimSuffix(string(line), "\n")
+		if len(req.Commands) == 0 {
+			var capabilities string
+			text, capabilities, _ = strings.Cut(text, "\x00")
+			req.sideband = hasCapability(capabilities, "side-band-64k")
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed command %q", text)
+		}
+
+		old, err := ParseHash(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		next, err := ParseHash(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		req.Commands = append(req.Commands, Command{Old: old, New: next, Ref: fields[2]})
+	}
+
+	if len(req.Commands) == 0 {
+		return nil, ErrProbe
+	}
+	return req, nil
+}
+
+// Report returns a PushReport that writes to w, using side-band packets
+// when the client asked for them.
+func (p *PushRequest) Report(w io.Writer) *PushReport {
+	return &PushReport{w: w, sideband: p.sideband}
+}
+
+// Message shows a line of text to the user, which git prints prefixed with
+// "remote:". Messages are dropped for clients without side-band support.
+func (r *PushReport) Message(format string, args ...interface{}) error {
+	if !r.sideband {
+		return nil
+	}
+	msg := fmt.Sprintf(format, args...) + "\n"
+	_, err := (&sidebandWriter{w: r.w, band: bandProgress}).Write([]byte(msg))
+	return err
+}
+
+// Finish writes the report-status of the push. A nil unpackErr reports
+// that the pack was received; refErrors holds the reason each rejected ref
+// was not updated.
+func (r *PushReport) Finish(commands []Command, unpackErr error, refErrors map[string]string) error {
+	var status strings.Builder
+	if unpackErr != nil {
+		status.Write(pktLine("unpack " + unpackErr.Error() + "\n"))
+	} else {
+		status.Write(pktLine("unpack ok\n"))
+	}
+
+	for _, cmd := range commands {
+		if reason, ok := refErrors[cmd.Ref]; ok {
+			status.Write(pktLine("ng " + cmd.Ref + " " + reason + "\n"))
+		} else if unpackErr != nil {
+			status.Write(pktLine("ng " + cmd.Ref + " unpacker error\n"))
+		} else {
+			status.Write(pktLine("ok " + cmd.Ref + "\n"))
+		}
+	}
+	status.Write(flushPkt)
+
+	if !r.sideband {
+		_, err := io.WriteString(r.w, status.String())
+		return err
+	}
+
+	if _, err := (&sidebandWriter{w: r.w, band: bandData}).Write([]byte(status.String())); err != nil {
+		return err
+	}
+	_, err := r.w.Write(flushPkt)
+	return err
+}
+
+// FastForward returns the commits between old and next, oldest first. It
+// fails with ErrNonFastForward when next does not descend from old, and
+// only accepts linear history of at most limit commits.
+func FastForward(store *Store, old, next Hash, limit int) ([]Hash, error) {
+	var commits []Hash
+	for h := next; h != old; {
+		if len(commits) == limit {
+			return nil, ErrTooManyCommits
+		}
+
+		c, err := store.Commit(h)
+		if err != nil {
+			return nil, err
+		}
+		switch len(c.Parents) {
+		case 0:
+			return nil, ErrNonFastForward
+		case 1:
+		default:
+			return nil, ErrMergeCommit
+		}
+
+		commits = append(commits, h)
+		h = c.Parents[0]
+	}
+
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
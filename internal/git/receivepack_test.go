@@ -0,0 +1,117 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadPushCommands(t *testing.T) {
+	const (
+		zero = "0000000000000000000000000000000000000000"
+		old  = "1111111111111111111111111111111111111111"
+		next = "2222222222222222222222222222222222222222"
+	)
+
+	tests := []struct {
+		name     string
+		input    string
+		want     []Command
+		sideband bool
+		wantErr  error
+	}{
+		{
+			name:  "single command",
+			input: string(pktLine(old+" "+next+" refs/heads/main\n")) + "0000",
+			want:  []Command{{Old: mustHash(t, old), New: mustHash(t, next), Ref: "refs/heads/main"}},
+		},
+		{
+			name:     "capabilities on first command",
+			input:    string(pktLine(old+" "+next+" refs/heads/main\x00report-status side-band-64k agent=git/2.43.0\n")) + "0000",
+			want:     []Command{{Old: mustHash(t, old), New: mustHash(t, next), Ref: "refs/heads/main"}},
+			sideband: true,
+		},
+		{
+			name: "several commands",
+			input: string(pktLine(zero+" "+next+" refs/heads/main\x00report-status")) +
+				string(pktLine(old+" "+zero+" refs/heads/old")) + "0000",
+			want: []Command{
+				{New: mustHash(t, next), Ref: "refs/heads/main"},
+				{Old: mustHash(t, old), Ref: "refs/heads/old"},
+			},
+		},
+		{
+			name:    "probe",
+			input:   "0000",
+			wantErr: ErrProbe,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ReadPushCommands(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadPushCommands: %v", err)
+			}
+			if len(req.Commands) != len(tt.want) {
+				t.Fatalf("got %d commands, want %d", len(req.Commands), len(tt.want))
+			}
+			for i, c := range req.Commands {
+				if c != tt.want[i] {
+					t.Errorf("command %d = %+v, want %+v", i, c, tt.want[i])
+				}
+			}
+			if req.sideband != tt.sideband {
+				t.Errorf("sideband = %v, want %v", req.sideband, tt.sideband)
+			}
+		})
+	}
+}
+
+func TestReadPushCommandsMalformed(t *testing.T) {
+	const hash = "1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"missing flush", string(pktLine(hash + " " + hash + " refs/heads/main"))},
+		{"invalid length", "zzzz"},
+		{"length below header", "0003"},
+		{"length above maximum", "fff5"},
+		{"truncated line", "0030" + hash},
+		{"too few fields", string(pktLine(hash+" refs/heads/main")) + "0000"},
+		{"too many fields", string(pktLine(hash+" "+hash+" refs/heads/main extra")) + "0000"},
+		{"invalid old hash", string(pktLine("xyz "+hash+" refs/heads/main")) + "0000"},
+		{"invalid new hash", string(pktLine(hash+" "+hash[:39]+" refs/heads/main")) + "0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadPushCommands(bufio.NewReader(strings.NewReader(tt.input)))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if errors.Is(err, ErrProbe) {
+				t.Fatalf("malformed request reported as a probe: %v", err)
+			}
+		})
+	}
+}
+
+func mustHash(t *testing.T, s string) Hash {
+	t.Helper()
+	h, err := ParseHash(s)
+	if err != nil {
+		t.Fatalf("ParseHash(%q): %v", s, err)
+	}
+	return h
+}
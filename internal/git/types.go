@@ -0,0 +1,114 @@
+package git
+
+import (
+	"encoding/hex"
+	"io"
+	"time"
+)
+
+// Hash is the SHA-1 ID of a Git object.
+type Hash [20]byte
+
+// String returns the hex form of the hash.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// IsZero reports whether h is the all-zero hash Git uses for missing refs.
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// ObjectType identifies the kind of a Git object. The values match the
+// type codes used in pack files.
+type ObjectType int
+
+const (
+	ObjectCommit ObjectType = 1
+	ObjectTree   ObjectType = 2
+	ObjectBlob   ObjectType = 3
+	ObjectTag    ObjectType = 4
+)
+
+// Object is a Git object held in memory.
+type Object struct {
+	Type ObjectType
+	Data []byte
+}
+
+// Store is an in-memory object database. Objects are kept in insertion
+// order so that generated packs are deterministic.
+type Store struct {
+	objects map[Hash]*Object
+	order   []Hash
+}
+
+// File is a file of a snapshot.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Signature identifies the author or committer of a commit.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+// Snapshot is the state of a gist's files at one revision. Commit holds
+// the verbatim commit object of a pushed revision, which is reused so that
+// its hash does not change; otherwise a commit is built from Message and
+// Author.
+type Snapshot struct {
+	Files   []File
+	Message string
+	Author  Signature
+	Commit  []byte
+}
+
+// Commit is a parsed commit object.
+type Commit struct {
+	Tree    Hash
+	Parents []Hash
+	Message string
+}
+
+// TreeEntry is an entry of a parsed tree object.
+type TreeEntry struct {
+	Mode string
+	Name string
+	Hash Hash
+}
+
+// PackLimits bounds what a pack sent by a client may hold. The sizes a pack
+// declares are checked against them before anything is allocated.
+type PackLimits struct {
+	// MaxPackSize is the most the pack itself can take up on the wire.
+	MaxPackSize int64
+	// MaxObjects is the most objects the pack can contain.
+	MaxObjects int
+	// MaxObjectSize bounds the inflated size of each object and delta.
+	MaxObjectSize int64
+	// MaxTotalSize bounds the inflated size of all objects together.
+	MaxTotalSize int64
+}
+
+// Command is a ref update requested by a push.
+type Command struct {
+	Old Hash
+	New Hash
+	Ref string
+}
+
+// PushRequest is a parsed receive-pack request.
+type PushRequest struct {
+	Commands []Command
+	sideband bool
+}
+
+// PushReport writes the outcome of a push back to the client.
+type PushReport struct {
+	w        io.Writer
+	sideband bool
+}
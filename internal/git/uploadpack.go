@@ -0,0 +1,165 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Service names of the smart HTTP protocol.
+const (
+	UploadPack  = "git-upload-pack"
+	ReceivePack = "git-receive-pack"
+)
+
+// DefaultBranch is the only branch of a gist repository.
+const DefaultBranch = "refs/heads/main"
+
+const (
+	agent                  = "agent=quickgist"
+	uploadPackCapabilities = "side-band-64k no-progress symref=HEAD:" + DefaultBranch + " " + agent
+)
+
+var (
+	// ErrNotOurRef is returned when a client wants an object the repository
+	// does not advertise.
+	ErrNotOurRef = errors.New("not our ref")
+
+	// ErrProbe is returned for a request that holds nothing but a flush
+	// packet. git sends one before a large request to check that the
+	// server accepts it, and expects an empty successful response.
+	ErrProbe = errors.New("probe request")
+)
+
+// AdvertiseRefs writes the info/refs response of the smart HTTP protocol
+// for a repository whose only branch points at head. An empty repository
+// is advertised with the capabilities alone.
+func AdvertiseRefs(w io.Writer, service string, head Hash, capabilities string) error {
+	var buf bytes.Buffer
+	buf.Write(pktLine("# service=" + service + "\n"))
+	buf.Write(flushPkt)
+
+	if head.IsZero() {
+		buf.Write(pktLine(Hash{}.String() + " capabilities^{}\x00" + capabilities + "\n"))
+	} else {
+		buf.Write(pktLine(head.String() + " HEAD\x00" + capabilities + "\n"))
+		buf.Write(pktLine(head.String() + " " + DefaultBranch + "\n"))
+	}
+	buf.Write(flushPkt)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// AdvertiseUploadPack writes the upload-pack ref advertisement.
+func AdvertiseUploadPack(w io.Writer, head Hash) error {
+	return AdvertiseRefs(w, UploadPack, head, uploadPackCapabilities)
+}
+
+// uploadRequest is a parsed upload-pack negotiation.
+type uploadRequest struct {
+	wants    []Hash
+	sideband bool
+}
+
+// ServeUploadPack answers an upload-pack request by sending every object
+// of the store. Probes yield ErrProbe without writing anything. Since a gist history is tiny, the server does not
+// negotiate common commits and always sends a complete pack.
+func ServeUploadPack(w io.Writer, body io.Reader, store *Store) error {
+	req, err := parseUploadRequest(bufio.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for _, want := range req.wants {
+		if obj, ok := store.Get(want); !ok || obj.Type != ObjectCommit {
+			return ErrNotOurRef
+		}
+	}
+
+	if _, err := w.Write(pktLine("NAK\n")); err != nil {
+		return err
+	}
+
+	if !req.sideband {
+		return WritePack(w, store)
+	}
+
+	if err := WritePack(&sidebandWriter{w: w, band: bandData}, store); err != nil {
+		return err
+	}
+	_, err = w.Write(flushPkt)
+	return err
+}
+
+func parseUploadRequest(r *bufio.Reader) (*uploadRequest, error) {
+	req := &uploadRequest{}
+
+	for {
+		line, err := readPktLine(r)
+		if err == errFlush {
+			// Wants end with a flush; haves follow until "done".
+			if len(req.wants) == 0 {
+				return nil, ErrProbe
+			}
+			continue
+		}
+		if err == io.EOF && len(req.wants) > 0 {
+			return req, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		text := strings.TrimSuffix(string(line), "\n")
+		switch {
+		case strings.HasPrefix(text, "want "):
+			fields := strings.SplitN(text[len("want "):], " ", 2)
+			h, err := ParseHash(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			req.wants = append(req.wants, h)
+			if len(fields) == 2 && hasCapability(fields[1], "side-band-64k") {
+				req.sideband = true
+			}
+		case strings.HasPrefix(text, "have "), strings.HasPrefix(text, "shallow "), strings.HasPrefix(text, "deepen"):
+		case text == "done":
+			return req, nil
+		default:
+			return nil, fmt.Errorf("unexpected line %q", text)
+		}
+	}
+}
+
+// WriteError reports a fatal error to the client as an ERR packet, which
+// git prints before aborting.
+func WriteError(w io.Writer, service string, err error) error {
+	_, werr := w.Write(pktLine("ERR " + service + ": " + err.Error() + "\n"))
+	return werr
+}
+
+// ParseHash parses the hex form of an object ID.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != hex.EncodedLen(len(h)) {
+		return h, fmt.Errorf("invalid object id %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object id %q", s)
+	}
+	return h, nil
+}
+
+func hasCapability(capabilities, name string) bool {
+	for _, c := range strings.Fields(capabilities) {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+const maxDeleteAccountRequestSize = 4 << 10
+
+// PreviewAccountDeletion handles GET /me/deletion, reporting what deleting
+// the token owner's account would remove without changing anything.
+func (h *Handler) PreviewAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	report, err := h.deleteAccount(r.Context(), callerID, true)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// DeleteAccount handles DELETE /me
+//
+// The caller must authenticate with an API token and confirms by sending
+// their user ID, which guards against accidental calls only. Gists,
+// attachments, stars, webhooks with their delivery logs, exports and API
+// tokens are deleted; comments on other users' gists are removed, or
+// blanked and anonymized when they have replies. A failed deletion can be
+// retried.
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	var req DeleteAccountRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxDeleteAccountRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+	if strings.TrimSpace(req.Confirm) != callerID {
+		h.respondError(w, apperror.Validation("confirm must be set to your user ID"))
+		return
+	}
+
+	running, err := h.runningExport(r.Context(), callerID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if running != nil {
+		h.respondError(w, apperror.Conflict("wait for the running export to finish"))
+		return
+	}
+
+	report, err := h.deleteAccount(r.Context(), callerID, false)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.infoLog.Printf("deleted account %s: %d gists, %d comments", callerID, report.Gists, report.Comments)
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// deleteAccount deletes everything a user stored, or only counts it when
+// dryRun is set. Stars go first since unstarring a deleted gist would
+// recreate its counter, and tokens go last so that a failed deletion can
+// be retried with the same token.
+func (h *Handler) deleteAccount(ctx context.Context, userID string, dryRun bool) (*DeletionReport, error) {
+	report := &DeletionReport{DryRun: dryRun}
+
+	stars, err := h.userStars(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stars {
+		if !dryRun {
+			if _, err := h.repo.Unstar(ctx, userID, s.GistID); err != nil {
+				return nil, err
+			}
+		}
+		report.Stars++
+	}
+
+	gists, err := h.userGists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[string]bool, len(gists))
+	for _, g := range gists {
+		owned[g.ID] = true
+		if !dryRun {
+			if err := h.deleteGist(ctx, g); err != nil {
+				return nil, err
+			}
+		}
+		report.Gists++
+		if g.FileName != "" {
+			report.Attachments++
+		}
+	}
+
+	// Comments on the user's own gists were deleted with them.
+	comments, err := h.repo.ListUserComments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comments {
+		if owned[c.GistID] {
+			continue
+		}
+		if !dryRun {
+			if err := h.eraseComment(ctx, c, true); err != nil {
+				return nil, err
+			}
+		}
+		report.Comments++
+	}
+
+	webhooks, err := h.repo.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range webhooks {
+		if !dryRun {
+			if err := h.repo.DeleteWebhook(ctx, hook.ID); err != nil {
+				return nil, err
+			}
+		}
+		report.Webhooks++
+	}
+
+	// Deliveries name the user and carry gist payloads, so they go along
+	// with the webhooks, including the logs of webhooks deleted earlier.
+	if dryRun {
+		report.Deliveries, err = h.repo.CountUserDeliveries(ctx, userID)
+	} else {
+		report.Deliveries, err = h.repo.DeleteUserDeliveries(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	exports, err := h.repo.ListExports(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range exports {
+		if !dryRun {
+			h.deleteExport(ctx, e)
+		}
+		report.Exports++
+	}
+
+	tokens, err := h.repo.ListTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if !dryRun {
+			if err := h.repo.DeleteToken(ctx, t.ID); err != nil {
+				return nil, err
+			}
+		}
+		report.Tokens++
+	}
+
+	return report, nil
+}
+
+// userStars loads every star of a user.
+func (h *Handler) userStars(ctx context.Context, userID string) ([]*model.Star, error) {
+	var stars []*model.Star
+	var opts repository.ListOptions
+
+	for {
+		page, next, err := h.repo.ListStars(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		stars = append(stars, page...)
+
+		if next == "" {
+			return stars, nil
+		}
+		opts.Cursor = next
+	}
+}
+
+// eraseComment deletes a comment, or blanks it when it has replies so that
+// the thread stays intact. Anonymized comments also lose their author.
+func (h *Handler) eraseComment(ctx context.Context, comment *model.Comment, anonymize bool) error {
+	comments, err := h.repo.ListComments(ctx, comment.GistID)
+	if err != nil {
+		return err
+	}
+
+	hasReplies := false
+	for _, c := range comments {
+		if c.ParentID == comment.ID {
+			hasReplies = true
+			break
+		}
+	}
+
+	if !hasReplies {
+		return h.repo.DeleteComment(ctx, comment.GistID, comment.ID)
+	}
+
+	comment.Body = ""
+	comment.Deleted = true
+	comment.UpdatedAt = time.Now().UTC()
+	if anonymize {
+		comment.UserID = ""
+	}
+	return h.repo.UpdateComment(ctx, comment)
+}
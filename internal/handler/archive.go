@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const manifestFileName = "manifest.json"
+
+// archiveFile is a single entry streamed into an archive.
+type archiveFile struct {
+	name    string
+	kind    string
+	size    int64
+	modTime time.Time
+	content io.Reader
+}
+
+// archiveWriter abstracts over the zip and tar.gz formats.
+type archiveWriter interface {
+	writeFile(name string, size int64, modTime time.Time, content io.Reader) error
+	Close() error
+}
+
+// ArchiveZip handles GET /gist/:id/archive.zip
+func (h *Handler) ArchiveZip(w http.ResponseWriter, r *http.Request) {
+	h.serveArchive(w, r, "zip", "application/zip", func(w io.Writer) archiveWriter {
+		return &zipArchive{zw: zip.NewWriter(w)}
+	})
+}
+
+// ArchiveTarGz handles GET /gist/:id/archive.tar.gz
+func (h *Handler) ArchiveTarGz(w http.ResponseWriter, r *http.Request) {
+	h.serveArchive(w, r, "tar.gz", "application/gzip", func(w io.Writer) archiveWriter {
+		gz := gzip.NewWriter(w)
+		return &tarArchive{gz: gz, tw: tar.NewWriter(gz)}
+	})
+}
+
+// serveArchive streams the gist content, its attachments and a metadata
+// manifest straight to the client without buffering them in memory.
+func (h *Handler) serveArchive(
+	w http.ResponseWriter,
+	r *http.Request,
+	ext, contentType string,
+	newWriter func(io.Writer) archiveWriter,
+) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.allowTransfer(w)
+
+	files := []archiveFile{{
+		name:    gist.ContentFileName(),
+		kind:    "content",
+		size:    int64(len(gist.Content)),
+		modTime: gist.CreatedAt,
+		content: strings.NewReader(gist.Content),
+	}}
+
+	// Attachments are opened before anything is written so that a missing
+	// object still produces a proper error response.
+	if gist.FileName != "" {
+		reader, info, err := h.storage.Open(r.Context(), gist.ID, gist.FileName)
+		if err != nil {
+			h.respondError(w, err)
+			return
+		}
+		defer reader.Close()
+
+		files = append(files, archiveFile{
+			name:    path.Base(gist.FileName),
+			kind:    "attachment",
+			size:    info.Size,
+			modTime: gist.CreatedAt,
+			content: reader,
+		})
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", gist.ID+"."+ext))
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	archive := newWriter(w)
+	if err := writeArchive(archive, gist, files); err != nil {
+		h.errorLog.Printf("error streaming archive for gist %s: %v", gist.ID, err)
+		return
+	}
+	if err := archive.Close(); err != nil {
+		h.errorLog.Printf("error finishing archive for gist %s: %v", gist.ID, err)
+	}
+}
+
+func writeArchive(archive archiveWriter, gist *model.Gist, files []archiveFile) error {
+	root := gist.ID + "/"
+
+	manifest := ArchiveManifest{
+		SnippetID:   gist.ID,
+		Title:       gist.Title,
+		Description: gist.Description,
+		Language:    gist.ResolvedLanguage(),
+		Visibility:  string(gist.Visibility),
+		IsDraft:     gist.IsDraft,
+		CreatedAt:   gist.CreatedAt,
+		UserID:      gist.UserID,
+	}
+
+	for _, f := range files {
+		if err := archive.writeFile(root+f.name, f.size, f.modTime, f.content); err != nil {
+			return fmt.Errorf("writing %s: %w", f.name, err)
+		}
+		manifest.Files = append(manifest.Files, ArchiveManifestFile{
+			Name: f.name,
+			Kind: f.kind,
+			Size: f.size,
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return archive.writeFile(root+manifestFileName, int64(len(data)), time.Now().UTC(), strings.NewReader(string(data)))
+}
+
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchive) writeFile(name string, size int64, modTime time.Time, content io.Reader) error {
+	fw, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fw, content)
+	return err
+}
+
+func (a *zipArchive) Close() error {
+	return a.zw.Close()
+}
+
+type tarArchive struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (a *tarArchive) writeFile(name string, size int64, modTime time.Time, content io.Reader) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: modTime,
+		Format:  tar.FormatPAX,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(a.tw, content)
+	return err
+}
+
+func (a *tarArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
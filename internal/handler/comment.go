@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/render"
+)
+
+const (
+	maxCommentSize     = 64 << 10
+	maxRevisionLength  = 64
+	maxCommentBodySize = maxCommentSize + 4<<10
+)
+
+// ListComments handles GET /gist/:id/comments
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	comments, err := h.repo.ListComments(r.Context(), gist.ID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, CommentsResponse{Comments: h.commentThreads(comments)})
+}
+
+// CreateComment handles POST /gist/:id/comments
+//
+// The comment is posted as the owner of the API token.
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return
+	}
+
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	var req CreateCommentRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxCommentBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+
+	body, err := validateCommentBody(req.Body)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	comment := model.NewComment(gist.ID, callerID, body)
+
+	if parentID := strings.TrimSpace(req.ParentID); parentID != "" {
+		if req.Anchor != nil {
+			h.respondError(w, apperror.Validation("replies cannot be anchored"))
+			return
+		}
+
+		parent, err := h.repo.GetComment(r.Context(), gist.ID, parentID)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			h.respondError(w, apperror.Validation("parent comment not found"))
+			return
+		}
+		if err != nil {
+			h.respondError(w, err)
+			return
+		}
+		comment.WithParent(parent.ID)
+	}
+
+	if req.Anchor != nil {
+		anchor, err := validateAnchor(gist, *req.Anchor)
+		if err != nil {
+			h.respondError(w, err)
+			return
+		}
+		comment.WithAnchor(anchor)
+	}
+
+	commentID, err := h.repo.CreateComment(r.Context(), comment)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	comment.ID = commentID
+
+	h.respondJSON(w, http.StatusCreated, h.commentToResponse(comment))
+}
+
+// UpdateComment handles PATCH /gist/:id/comments/:commentId for the
+// comment's author.
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	var req UpdateCommentRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxCommentBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+
+	body, err := validateCommentBody(req.Body)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	_, comment, err := h.getComment(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if comment.UserID != callerID {
+		h.respondError(w, apperror.Forbidden("only the author can edit this comment"))
+		return
+	}
+
+	comment.Body = body
+	comment.UpdatedAt = time.Now().UTC()
+
+	if err := h.repo.UpdateComment(r.Context(), comment); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.commentToResponse(comment))
+}
+
+// DeleteComment handles DELETE /gist/:id/comments/:commentId
+//
+// Authors can delete their own comments and gist owners can delete any
+// comment on their gists, both identified by their API token. Comments
+// with replies are blanked rather than removed so that the thread stays
+// intact.
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	gist, comment, err := h.getComment(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if comment.UserID != callerID && gist.UserID != callerID {
+		h.respondError(w, apperror.Forbidden("only the author or the gist owner can delete this comment"))
+		return
+	}
+
+	if err := h.eraseComment(r.Context(), comment, false); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getComment loads the gist and the live comment addressed by the request.
+func (h *Handler) getComment(r *http.Request) (*model.Gist, *model.Comment, error) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	commentID := strings.TrimSpace(chi.URLParam(r, "commentId"))
+
+	if id == "" || commentID == "" {
+		return nil, nil, apperror.BadRequest("gist ID and comment ID are required")
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comment, err := h.repo.GetComment(r.Context(), gist.ID, commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if comment.Deleted {
+		return nil, nil, apperror.NotFound("comment")
+	}
+
+	return gist, comment, nil
+}
+
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", apperror.Validation("body is required")
+	}
+	if len(body) > maxCommentSize {
+		return "", apperror.Validation("body exceeds maximum size")
+	}
+	return body, nil
+}
+
+// validateAnchor checks that an anchor refers to the gist content or its
+// attachment. Line ranges can only be checked against the content.
+func validateAnchor(gist *model.Gist, req CommentAnchor) (*model.CommentAnchor, error) {
+	anchor := &model.CommentAnchor{
+		FileName:  strings.TrimSpace(req.FileName),
+		StartLine: req.StartLine,
+		EndLine:   req.EndLine,
+		Revision:  strings.TrimSpace(req.Revision),
+	}
+
+	contentFile := gist.ContentFileName()
+	if anchor.FileName == "" {
+		anchor.FileName = contentFile
+	}
+	if anchor.FileName != contentFile && anchor.FileName != gist.FileName {
+		return nil, apperror.Validation("anchor file does not belong to the gist")
+	}
+
+	if anchor.EndLine == 0 {
+		anchor.EndLine = anchor.StartLine
+	}
+	if anchor.StartLine < 1 || anchor.EndLine < anchor.StartLine {
+		return nil, apperror.Validation("anchor lines must be a positive, ascending range")
+	}
+	if anchor.FileName == contentFile && anchor.EndLine > strings.Count(gist.Content, "\n")+1 {
+		return nil, apperror.Validation("anchor lines are outside the file")
+	}
+
+	if len(anchor.Revision) > maxRevisionLength {
+		return nil, apperror.Validation("anchor revision is too long")
+	}
+
+	return anchor, nil
+}
+
+// commentThreads arranges comments into threads, keeping their order.
+// Replies whose parent is missing are shown at the top level.
+func (h *Handler) commentThreads(comments []*model.Comment) []CommentResponse {
+	ids := make(map[string]bool, len(comments))
+	for _, c := range comments {
+		ids[c.ID] = true
+	}
+
+	children := make(map[string][]*model.Comment)
+	for _, c := range comments {
+		parentID := c.ParentID
+		if !ids[parentID] {
+			parentID = ""
+		}
+		children[parentID] = append(children[parentID], c)
+	}
+
+	var build func(parentID string) []CommentResponse
+	build = func(parentID string) []CommentResponse {
+		var thread []CommentResponse
+		for _, c := range children[parentID] {
+			resp := h.commentToResponse(c)
+			resp.Replies = build(c.ID)
+			thread = append(thread, resp)
+		}
+		return thread
+	}
+
+	threads := build("")
+	if threads == nil {
+		threads = []CommentResponse{}
+	}
+	return threads
+}
+
+func (h *Handler) commentToResponse(c *model.Comment) CommentResponse {
+	resp := CommentResponse{
+		ID:        c.ID,
+		ParentID:  c.ParentID,
+		UserID:    c.UserID,
+		Body:      c.Body,
+		Deleted:   c.Deleted,
+		CreatedAt: c.CreatedAt,
+	}
+
+	if c.Body != "" {
+		html, err := render.Markdown(c.Body)
+		if err != nil {
+			h.errorLog.Printf("failed to render comment %s: %v", c.ID, err)
+		}
+		resp.BodyHTML = html
+	}
+	if c.Anchor != nil {
+		resp.Anchor = &CommentAnchor{
+			FileName:  c.Anchor.FileName,
+			StartLine: c.Anchor.StartLine,
+			EndLine:   c.Anchor.EndLine,
+			Revision:  c.Anchor.Revision,
+		}
+	}
+	if !c.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &c.UpdatedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/render"
+)
+
+const (
+	embedStylesheetPath = "/assets/embed.css"
+	embedCacheControl   = "public, max-age=300"
+)
+
+var (
+	embedFragmentTemplate = template.Must(template.New("fragment").Parse(
+		`<div class="qg-embed" id="qg-embed-{{.ID}}">` +
+			`<div class="qg-embed-file">` +
+			`<div class="qg-embed-body">{{.Code}}</div>` +
+			`<div class="qg-embed-meta">` +
+			`<a href="{{.ViewURL}}" target="_blank" rel="noopener">{{.Title}}</a>` +
+			` hosted with QuickGist` +
+			`</div>` +
+			`</div>` +
+			`</div>`,
+	))
+
+	embedPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · QuickGist</title>
+<link rel="stylesheet" href="{{.StylesheetURL}}">
+</head>
+<body class="qg-embed-page">
+{{.Fragment}}
+</body>
+</html>
+`))
+)
+
+// EmbedScript handles GET /gist/:id.js
+func (h *Handler) EmbedScript(w http.ResponseWriter, r *http.Request) {
+	gist, fragment, ok := h.embedFragment(w, r)
+	if !ok {
+		return
+	}
+
+	stylesheet := fmt.Sprintf(`<link rel="stylesheet" href="%s">`,
+		template.HTMLEscapeString(h.baseURL(r)+embedStylesheetPath))
+
+	// JSON string literals are valid JavaScript and escape "<" and ">",
+	// so the markup cannot terminate the host page's script element.
+	stylesheetJS, err := json.Marshal(stylesheet)
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+	fragmentJS, err := json.Marshal(fragment)
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	setGistCacheControl(w, gist, embedCacheControl)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "// QuickGist embed for %s\ndocument.write(%s);\ndocument.write(%s);\n",
+		gist.ID, stylesheetJS, fragmentJS)
+}
+
+// Embed handles GET /gist/:id/embed
+func (h *Handler) Embed(w http.ResponseWriter, r *http.Request) {
+	gist, fragment, ok := h.embedFragment(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := embedPageTemplate.Execute(&buf, struct {
+		Title         string
+		StylesheetURL string
+		Fragment      template.HTML
+	}{
+		Title:         gist.Title,
+		StylesheetURL: embedStylesheetPath,
+		Fragment:      template.HTML(fragment),
+	})
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	setGistCacheControl(w, gist, embedCacheControl)
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+// EmbedStylesheet handles GET /assets/embed.css
+func (h *Handler) EmbedStylesheet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, render.EmbedStylesheet())
+}
+
+// embedFragment loads the gist named in the request and renders its
+// namespaced, highlighted HTML. It writes an error response and reports
+// false when the gist cannot be embedded.
+func (h *Handler) embedFragment(w http.ResponseWriter, r *http.Request) (*model.Gist, string, bool) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return nil, "", false
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		h.respondError(w, err)
+		return nil, "", false
+	}
+
+	code, err := render.Code(gist.Content, gist.ResolvedLanguage())
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return nil, "", false
+	}
+
+	var buf bytes.Buffer
+	err = embedFragmentTemplate.Execute(&buf, struct {
+		ID      string
+		Title   string
+		ViewURL string
+		Code    template.HTML
+	}{
+		ID:      gist.ID,
+		Title:   gist.Title,
+		ViewURL: h.viewURL(gist.ID),
+		Code:    template.HTML(code),
+	})
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return nil, "", false
+	}
+
+	return gist, buf.String(), true
+}
+
+// viewURL returns the web app URL where a gist can be viewed.
+func (h *Handler) viewURL(id string) string {
+	return h.config.Server.AppURL + "/view/" + url.PathEscape(id)
+}
@@ -0,0 +1,460 @@
+package handler
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+const (
+	// exportStorageID is the storage directory holding export archives. It
+	// cannot collide with a gist ID, which never starts with an underscore.
+	exportStorageID = "_exports"
+
+	exportTimeout        = 30 * time.Minute
+	exportRecordTimeout  = 30 * time.Second
+	maxConcurrentExports = 2
+
+	exportDataFileName = "data.jsonl"
+	exportFilesDir     = "attachments/"
+)
+
+// CreateExport handles POST /me/export
+//
+// The archive holds private data, so exports are only ever created for
+// and served to the owner of the API token. It is built in the
+// background; clients poll the returned export until it completes and
+// then download it. Each user keeps only their latest archive.
+func (h *Handler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	running, err := h.runningExport(r.Context(), callerID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if running != nil {
+		h.respondError(w, apperror.Conflict("an export is already in progress"))
+		return
+	}
+
+	export := model.NewExport(callerID)
+	id, err := h.repo.CreateExport(r.Context(), export)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	export.ID = id
+
+	h.startExport(export)
+
+	w.Header().Set("Location", "/me/exports/"+id)
+	h.respondJSON(w, http.StatusAccepted, exportToResponse(export))
+}
+
+// ListExports handles GET /me/exports
+func (h *Handler) ListExports(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	exports, err := h.repo.ListExports(r.Context(), callerID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	resp := ExportListResponse{Exports: make([]ExportResponse, len(exports))}
+	for i, e := range exports {
+		resp.Exports[i] = exportToResponse(e)
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// GetExport handles GET /me/exports/:id
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	export, err := h.getOwnExport(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, exportToResponse(export))
+}
+
+// DownloadExport handles GET /me/exports/:id/download
+func (h *Handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	export, err := h.getOwnExport(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if export.Status != model.ExportComplete {
+		h.respondError(w, apperror.Conflict("the export is not complete"))
+		return
+	}
+	h.allowTransfer(w)
+
+	reader, info, err := h.storage.Open(r.Context(), exportStorageID, export.FileName)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	defer reader.Close()
+
+	name := "quickgist-export-" + export.CreatedAt.Format("2006-01-02") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Content-Length", fmt.Sprint(info.Size))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, reader); err != nil {
+		h.errorLog.Printf("error streaming export %s: %v", export.ID, err)
+	}
+}
+
+// getOwnExport loads the export addressed by the request, hiding exports
+// of anyone but the token owner.
+func (h *Handler) getOwnExport(r *http.Request) (*model.Export, error) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := strings.TrimSpace(chi.URLParam(r, "exportId"))
+	if id == "" {
+		return nil, apperror.BadRequest("export ID is required")
+	}
+
+	export, err := h.repo.GetExport(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if export.UserID != callerID {
+		return nil, apperror.NotFound("export")
+	}
+
+	return export, nil
+}
+
+// runningExport returns the user's export that is still being built, if
+// any. Pending exports older than exportTimeout were abandoned by a
+// server that stopped, so they are marked failed instead.
+func (h *Handler) runningExport(ctx context.Context, userID string) (*model.Export, error) {
+	exports, err := h.repo.ListExports(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range exports {
+		if e.Status != model.ExportPending {
+			continue
+		}
+		if time.Since(e.CreatedAt) < exportTimeout {
+			return e, nil
+		}
+
+		e.Status = model.ExportFailed
+		e.Error = "the export was interrupted"
+		if err := h.repo.UpdateExport(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// startExport builds an export in the background. At most
+// maxConcurrentExports archives are built at once.
+func (h *Handler) startExport(export *model.Export) {
+	h.exportJobs.Add(1)
+	go func() {
+		defer h.exportJobs.Done()
+
+		select {
+		case h.exportSlots <- struct{}{}:
+		case <-h.exportCtx.Done():
+			h.finishExport(export, h.exportCtx.Err())
+			return
+		}
+		defer func() { <-h.exportSlots }()
+
+		ctx, cancel := context.WithTimeout(h.exportCtx, exportTimeout)
+		defer cancel()
+
+		h.finishExport(export, h.buildExport(ctx, export))
+	}()
+}
+
+// finishExport records the outcome of an export and, once it succeeded,
+// removes the user's older exports.
+func (h *Handler) finishExport(export *model.Export, buildErr error) {
+	// The export may have been cancelled by a shutdown, which must not
+	// keep its outcome from being recorded.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(h.exportCtx), exportRecordTimeout)
+	defer cancel()
+
+	export.CompletedAt = time.Now().UTC()
+	export.Status = model.ExportComplete
+	if buildErr != nil {
+		h.errorLog.Printf("failed to build export %s: %v", export.ID, buildErr)
+		export.Status = model.ExportFailed
+		export.Error = "the export could not be built"
+	}
+
+	if err := h.repo.UpdateExport(ctx, export); err != nil {
+		h.errorLog.Printf("failed to update export %s: %v", export.ID, err)
+		return
+	}
+	if buildErr != nil {
+		return
+	}
+
+	exports, err := h.repo.ListExports(ctx, export.UserID)
+	if err != nil {
+		h.errorLog.Printf("failed to list exports of user %s: %v", export.UserID, err)
+		return
+	}
+	for _, e := range exports {
+		if e.ID != export.ID && e.Status != model.ExportPending {
+			h.deleteExport(ctx, e)
+		}
+	}
+}
+
+// deleteExport removes an export and its archive. Failures are only logged.
+func (h *Handler) deleteExport(ctx context.Context, export *model.Export) {
+	if export.FileName != "" {
+		if err := h.storage.Delete(ctx, exportStorageID, export.FileName); err != nil {
+			h.errorLog.Printf("failed to delete archive of export %s: %v", export.ID, err)
+		}
+	}
+	if err := h.repo.DeleteExport(ctx, export.ID); err != nil {
+		h.errorLog.Printf("failed to delete export %s: %v", export.ID, err)
+	}
+}
+
+// buildExport writes the archive of an export to a temporary file and
+// stores it. The archive holds data.jsonl, with a record for every gist
+// followed by its revisions and then one for every comment of the user,
+// and the attachments under attachments/<gistId>/, where revision
+// attachments are named revisions/<attachmentHash>.
+func (h *Handler) buildExport(ctx context.Context, export *model.Export) error {
+	tmp, err := os.CreateTemp("", "quickgist-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := h.writeExport(ctx, tmp, export); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	export.FileName = export.ID + ".zip"
+	export.Size = size
+	_, err = h.storage.Upload(ctx, exportStorageID, export.FileName, tmp, size)
+	return err
+}
+
+// exportFile is an attachment to copy into an export archive.
+type exportFile struct {
+	gistID  string
+	name    string
+	modTime time.Time
+}
+
+func (h *Handler) writeExport(ctx context.Context, w io.Writer, export *model.Export) error {
+	gists, err := h.userGists(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+	comments, err := h.repo.ListUserComments(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	data, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     exportDataFileName,
+		Method:   zip.Deflate,
+		Modified: export.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(data)
+
+	var files []exportFile
+	for _, g := range gists {
+		gist := h.gistToResponse(g)
+		if err := enc.Encode(ExportRecord{Type: "gist", Gist: &gist}); err != nil {
+			return err
+		}
+		export.Gists++
+
+		if g.FileName != "" {
+			files = append(files, exportFile{gistID: g.ID, name: g.FileName, modTime: gistModified(g)})
+		}
+
+		revisions, err := h.repo.ListRevisions(ctx, g.ID)
+		if err != nil {
+			return err
+		}
+
+		stored := make(map[string]bool)
+		for _, rev := range revisions {
+			if err := enc.Encode(ExportRecord{Type: "revision", Revision: revisionToExport(rev)}); err != nil {
+				return err
+			}
+			export.Revisions++
+
+			if rev.AttachmentHash != "" && !stored[rev.AttachmentHash] {
+				stored[rev.AttachmentHash] = true
+				files = append(files, exportFile{gistID: g.ID, name: revisionObject(rev.AttachmentHash), modTime: rev.CreatedAt})
+			}
+		}
+	}
+
+	for _, c := range comments {
+		comment := ExportComment{GistID: c.GistID, CommentResponse: h.commentToResponse(c)}
+		if err := enc.Encode(ExportRecord{Type: "comment", Comment: &comment}); err != nil {
+			return err
+		}
+	}
+
+	// A missing attachment is left out rather than failing the export.
+	for _, f := range files {
+		err := h.writeExportFile(ctx, zw, f)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			h.errorLog.Printf("export %s: %s of gist %s is missing", export.ID, f.name, f.gistID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		export.Attachments++
+	}
+
+	return zw.Close()
+}
+
+func (h *Handler) writeExportFile(ctx context.Context, zw *zip.Writer, f exportFile) error {
+	reader, _, err := h.storage.Open(ctx, f.gistID, f.name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     exportFilesDir + f.gistID + "/" + f.name,
+		Method:   zip.Deflate,
+		Modified: f.modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fw, reader)
+	return err
+}
+
+// userGists loads every gist of a user, drafts included.
+func (h *Handler) userGists(ctx context.Context, userID string) ([]*model.Gist, error) {
+	var gists []*model.Gist
+	opts := repository.ListOptions{Ascending: true}
+
+	for {
+		page, next, err := h.repo.ListByUser(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		gists = append(gists, page...)
+
+		if next == "" {
+			return gists, nil
+		}
+		opts.Cursor = next
+	}
+}
+
+// closeExports waits for running exports to finish, cancelling them when
+// ctx expires first.
+func (h *Handler) closeExports(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.exportJobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		h.cancelExports()
+		return fmt.Errorf("cancelling exports: %w", ctx.Err())
+	}
+}
+
+func revisionToExport(rev *model.Revision) *ExportRevision {
+	return &ExportRevision{
+		GistID:          rev.GistID,
+		Number:          rev.Number,
+		Message:         rev.Message,
+		ContentFileName: rev.ContentFileName,
+		Content:         rev.Content,
+		AttachmentName:  rev.AttachmentName,
+		AttachmentHash:  rev.AttachmentHash,
+		CreatedAt:       rev.CreatedAt,
+	}
+}
+
+func exportToResponse(e *model.Export) ExportResponse {
+	resp := ExportResponse{
+		ID:          e.ID,
+		Status:      string(e.Status),
+		Gists:       e.Gists,
+		Revisions:   e.Revisions,
+		Attachments: e.Attachments,
+		Size:        e.Size,
+		Error:       e.Error,
+		CreatedAt:   e.CreatedAt,
+	}
+
+	if !e.CompletedAt.IsZero() {
+		resp.CompletedAt = &e.CompletedAt
+	}
+	if e.Status == model.ExportComplete {
+		resp.DownloadURL = "/me/exports/" + e.ID + "/download"
+	}
+
+	return resp
+}
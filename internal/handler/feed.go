@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+const (
+	feedSize         = 20
+	feedCacheControl = "public, max-age=300"
+	atomNamespace    = "http://www.w3.org/2005/Atom"
+	jsonFeedVersion  = "https://jsonfeed.org/version/1.1"
+)
+
+// feedFields are the gist fields loaded to build feed entries.
+var feedFields = []string{"snippetId", "title", "description", "excerpt", "language", "tags", "createdAt", "updatedAt"}
+
+// AtomFeed handles GET /u/:userId/feed.atom
+func (h *Handler) AtomFeed(w http.ResponseWriter, r *http.Request) {
+	userID, gists, ok := h.feedGists(w, r)
+	if !ok {
+		return
+	}
+
+	feed := AtomFeed{
+		XMLNS:   atomNamespace,
+		ID:      h.feedURL(r, userID, "atom"),
+		Title:   "Gists by " + userID,
+		Updated: feedUpdated(gists).Format(time.RFC3339),
+		Author:  AtomPerson{Name: userID},
+		Links: []AtomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: h.feedURL(r, userID, "atom")},
+			{Rel: "alternate", Type: "text/html", Href: h.config.Server.AppURL},
+		},
+	}
+
+	for _, g := range gists {
+		entry := AtomEntry{
+			ID:        h.viewURL(g.ID),
+			Title:     g.Title,
+			Published: g.CreatedAt.Format(time.RFC3339),
+			Updated:   gistModified(g).Format(time.RFC3339),
+			Link:      AtomLink{Rel: "alternate", Type: "text/html", Href: h.viewURL(g.ID)},
+			Summary:   &AtomText{Type: "text", Body: feedSummary(g)},
+		}
+		for _, tag := range g.Tags {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+// JSONFeed handles GET /u/:userId/feed.json
+func (h *Handler) JSONFeed(w http.ResponseWriter, r *http.Request) {
+	userID, gists, ok := h.feedGists(w, r)
+	if !ok {
+		return
+	}
+
+	feed := JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       "Gists by " + userID,
+		HomePageURL: h.config.Server.AppURL,
+		FeedURL:     h.feedURL(r, userID, "json"),
+		Authors:     []JSONFeedAuthor{{Name: userID}},
+		Items:       make([]JSONFeedItem, len(gists)),
+	}
+
+	for i, g := range gists {
+		item := JSONFeedItem{
+			ID:            h.viewURL(g.ID),
+			URL:           h.viewURL(g.ID),
+			Title:         g.Title,
+			ContentText:   g.Excerpt,
+			Summary:       g.Description,
+			DatePublished: g.CreatedAt,
+			Tags:          g.Tags,
+		}
+		if !g.UpdatedAt.IsZero() {
+			item.DateModified = &g.UpdatedAt
+		}
+		feed.Items[i] = item
+	}
+
+	data, err := json.Marshal(feed)
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// feedGists loads the most recent public, non-draft gists of the user in
+// the URL and sets the validators feed readers poll with. It writes the
+// response itself and reports false when the request is already handled,
+// either by an error or by a 304 Not Modified.
+func (h *Handler) feedGists(w http.ResponseWriter, r *http.Request) (string, []*model.Gist, bool) {
+	userID := strings.TrimSpace(chi.URLParam(r, "userId"))
+	if userID == "" {
+		h.respondError(w, apperror.BadRequest("userId is required"))
+		return "", nil, false
+	}
+
+	isDraft := false
+	gists, _, err := h.repo.ListByUser(r.Context(), userID, repository.ListOptions{
+		Limit:      feedSize,
+		IsDraft:    &isDraft,
+		Visibility: model.VisibilityPublic,
+		Fields:     documentFields(feedFields),
+	})
+	if err != nil {
+		h.respondError(w, err)
+		return "", nil, false
+	}
+	gists = dropExpired(gists)
+
+	etag := feedETag(gists)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", feedCacheControl)
+
+	var lastModified time.Time
+	if len(gists) > 0 {
+		lastModified = feedUpdated(gists).Truncate(time.Second)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return "", nil, false
+	}
+
+	return userID, gists, true
+}
+
+// notModified evaluates the conditional request headers. If-None-Match
+// takes precedence over If-Modified-Since as required by RFC 9110.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := r.Header.Get("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !lastModified.After(t)
+	}
+
+	return false
+}
+
+// feedETag identifies the feed by the IDs and modification times of its
+// entries.
+func feedETag(gists []*model.Gist) string {
+	hash := sha256.New()
+	for _, g := range gists {
+		hash.Write([]byte(g.ID))
+		hash.Write([]byte(gistModified(g).Format(time.RFC3339Nano)))
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// feedUpdated returns when the most recently changed gist was modified,
+// or the current time for an empty feed.
+func feedUpdated(gists []*model.Gist) time.Time {
+	var updated time.Time
+	for _, g := range gists {
+		if t := gistModified(g); t.After(updated) {
+			updated = t
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now().UTC()
+	}
+	return updated
+}
+
+func gistModified(g *model.Gist) time.Time {
+	if g.UpdatedAt.After(g.CreatedAt) {
+		return g.UpdatedAt
+	}
+	return g.CreatedAt
+}
+
+func feedSummary(g *model.Gist) string {
+	if g.Description == "" {
+		return g.Excerpt
+	}
+	return g.Description + "\n\n" + g.Excerpt
+}
+
+func (h *Handler) feedURL(r *http.Request, userID, format string) string {
+	return h.baseURL(r) + "/u/" + url.PathEscape(userID) + "/feed." + format
+}
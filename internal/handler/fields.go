@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+// gistField describes a field that can be requested from listing endpoints
+// and the stored document fields needed to produce it.
+type gistField struct {
+	document []string
+	value    func(g *model.Gist) interface{}
+}
+
+var gistFields = map[string]gistField{
+	"snippetId": {
+		value: func(g *model.Gist) interface{} { return g.ID },
+	},
+	"title": {
+		document: []string{"title"},
+		value:    func(g *model.Gist) interface{} { return g.Title },
+	},
+	"description": {
+		document: []string{"description"},
+		value:    func(g *model.Gist) interface{} { return g.Description },
+	},
+	"content": {
+		document: []string{"content"},
+		value:    func(g *model.Gist) interface{} { return g.Content },
+	},
+	"excerpt": {
+		document: []string{"excerpt"},
+		value:    func(g *model.Gist) interface{} { return g.Excerpt },
+	},
+	"size": {
+		document: []string{"size"},
+		value:    func(g *model.Gist) interface{} { return g.Size },
+	},
+	"language": {
+		// Legacy documents without a stored language fall back to
+		// detection from fileName alone, since content is not loaded;
+		// quickgist-admin recompute stores the language detected from
+		// their content.
+		document: []string{"language", "fileName"},
+		value:    func(g *model.Gist) interface{} { return g.ResolvedLanguage() },
+	},
+	"tags": {
+		document: []string{"tags"},
+		value:    func(g *model.Gist) interface{} { return optionalStrings(g.Tags) },
+	},
+	"isDraft": {
+		document: []string{"isDraft"},
+		value:    func(g *model.Gist) interface{} { return g.IsDraft },
+	},
+	"visibility": {
+		document: []string{"visibility"},
+		value:    func(g *model.Gist) interface{} { return string(g.Visibility) },
+	},
+	"createdAt": {
+		document: []string{"createdAt"},
+		value:    func(g *model.Gist) interface{} { return g.CreatedAt },
+	},
+	"updatedAt": {
+		document: []string{"updatedAt"},
+		value: func(g *model.Gist) interface{} {
+			if g.UpdatedAt.IsZero() {
+				return nil
+			}
+			return g.UpdatedAt
+		},
+	},
+	"viewCount": {
+		document: []string{"viewCount"},
+		value:    func(g *model.Gist) interface{} { return g.ViewCount },
+	},
+	"downloadCount": {
+		document: []string{"downloadCount"},
+		value:    func(g *model.Gist) interface{} { return g.DownloadCount },
+	},
+	"userId": {
+		document: []string{"userId"},
+		value:    func(g *model.Gist) interface{} { return optionalString(g.UserID) },
+	},
+	"fileName": {
+		document: []string{"fileName"},
+		value:    func(g *model.Gist) interface{} { return optionalString(g.FileName) },
+	},
+	"fileURL": {
+		document: []string{"fileName", "publicFileURL"},
+		value:    func(g *model.Gist) interface{} { return optionalString(gistFileURL(g)) },
+	},
+	"fileSha256": {
+		document: []string{"fileSha256"},
+		value:    func(g *model.Gist) interface{} { return optionalString(g.FileSHA256) },
+	},
+}
+
+// summaryFields are returned by listing endpoints when no fields are
+// requested. They avoid loading the full content of every gist.
+var summaryFields = []string{
+	"snippetId",
+	"title",
+	"description",
+	"excerpt",
+	"size",
+	"language",
+	"tags",
+	"isDraft",
+	"visibility",
+	"createdAt",
+	"fileName",
+}
+
+// parseFields reads the comma-separated fields query parameter, defaulting
+// to the summary fields.
+func parseFields(r *http.Request) ([]string, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("fields"))
+	if raw == "" {
+		return summaryFields, nil
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := gistFields[name]; !ok {
+			return nil, apperror.Validation(fmt.Sprintf("unknown field %q", name))
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+
+	if len(fields) == 0 {
+		return summaryFields, nil
+	}
+
+	return fields, nil
+}
+
+// documentFields returns the stored document fields needed to produce the
+// given response fields. createdAt is always included since listings are
+// ordered and paginated by it, and expiresAt since they leave out expired
+// gists.
+func documentFields(fields []string) []string {
+	doc := []string{"createdAt", "expiresAt"}
+	seen := map[string]bool{"createdAt": true, "expiresAt": true}
+	for _, name := range fields {
+		for _, f := range gistFields[name].document {
+			if !seen[f] {
+				seen[f] = true
+				doc = append(doc, f)
+			}
+		}
+	}
+	return doc
+}
+
+// selectFields builds a response containing only the given fields.
+func selectFields(g *model.Gist, fields []string) GistFields {
+	resp := make(GistFields, len(fields))
+	for _, name := range fields {
+		if v := gistFields[name].value(g); v != nil {
+			resp[name] = v
+		}
+	}
+	return resp
+}
+
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func optionalStrings(s []string) interface{} {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
@@ -9,7 +9,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/julienschmidt/httprouter"
+	"github.com/go-chi/chi/v5"
 
 	"github.com/abhisheksharm-3/quickgist/internal/apperror"
 )
@@ -21,7 +21,7 @@ var (
 			MaxIdleConnsPerHost: 100,
 			IdleConnTimeout:     90 * time.Second,
 		},
-		Timeout: 30 * time.Second,
+		Timeout: transferTimeout,
 	}
 
 	safeFilenamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
@@ -29,9 +29,8 @@ var (
 
 // ServeFile handles GET /files/:snippetId/*filepath
 func (h *Handler) ServeFile(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	snippetID := strings.TrimSpace(params.ByName("snippetId"))
-	filepath := params.ByName("filepath")
+	snippetID := strings.TrimSpace(chi.URLParam(r, "snippetId"))
+	filepath := chi.URLParam(r, "*")
 
 	filepath = path.Clean(strings.TrimPrefix(filepath, "/"))
 
@@ -45,7 +44,7 @@ func (h *Handler) ServeFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gist, err := h.repo.Get(r.Context(), snippetID)
+	gist, err := h.getVisibleGist(r, snippetID)
 	if err != nil {
 		h.respondError(w, err)
 		return
@@ -55,6 +54,7 @@ func (h *Handler) ServeFile(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, apperror.NotFound("file"))
 		return
 	}
+	h.allowTransfer(w)
 
 	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, gist.FileURL, nil)
 	if err != nil {
@@ -73,6 +73,7 @@ func (h *Handler) ServeFile(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, apperror.Internal(fmt.Errorf("upstream returned %d", resp.StatusCode)))
 		return
 	}
+	h.counters.RecordDownload(gist.ID)
 
 	w.Header().Set("Content-Security-Policy", "default-src 'self'")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
@@ -86,7 +87,7 @@ func (h *Handler) ServeFile(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filepath))
 	}
 
-	w.Header().Set("Cache-Control", "public, max-age=3600")
+	setGistCacheControl(w, gist, "public, max-age=3600")
 	if etag := resp.Header.Get("ETag"); etag != "" {
 		w.Header().Set("ETag", etag)
 	}
@@ -1,134 +1,640 @@
 package handler
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/julienschmidt/httprouter"
+	"github.com/go-chi/chi/v5"
 
 	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/language"
 	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
 )
 
 const (
 	maxFileSize    = 10 << 20
 	maxContentSize = 1 << 20
+	maxUpdateSize  = maxContentSize + 64<<10
 	fileURLPattern = "/files/%s/%s"
 	cacheTTL       = 5 * time.Minute
+	minExpiry      = time.Minute
+	maxExpiry      = 365 * 24 * time.Hour
+
+	// stagingStorageID is the storage directory holding attachments of
+	// gists that are being created.
+	stagingStorageID = "_staging"
+	cleanupTimeout   = 30 * time.Second
 )
 
 // View handles GET /gist/view/:id
 func (h *Handler) View(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id := strings.TrimSpace(params.ByName("id"))
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
 
 	if id == "" {
 		h.respondError(w, apperror.BadRequest("gist ID is required"))
 		return
 	}
 
-	if cached, ok := h.cache.Get(id); ok {
-		h.respondJSON(w, http.StatusOK, h.gistToResponse(cached))
-		return
-	}
-
-	gist, err := h.repo.Get(r.Context(), id)
+	gist, err := h.getVisibleGist(r, id)
 	if err != nil {
 		h.respondError(w, err)
 		return
 	}
+	h.counters.RecordView(gist.ID)
 
-	h.cache.Set(id, gist, cacheTTL)
-	h.respondJSON(w, http.StatusOK, h.gistToResponse(gist))
+	resp := h.gistToResponse(gist)
+	resp.Stars = h.cachedStarCount(r.Context(), gist)
+
+	w.Header().Set("ETag", gistETag(gist))
+	if callerID := h.callerID(r); callerID != "" {
+		starred, err := h.repo.IsStarred(r.Context(), callerID, gist.ID)
+		if err != nil {
+			h.errorLog.Printf("failed to check star for gist %s: %v", gist.ID, err)
+		}
+		resp.Starred = starred
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
 }
 
 // Create handles POST /gist/create
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, maxFileSize)
-	if err := r.ParseMultipartForm(maxFileSize); err != nil {
-		h.respondError(w, apperror.BadRequest("request too large or invalid form"))
+	form, err := h.readCreateForm(w, r)
+	if err != nil {
+		h.respondError(w, err)
 		return
 	}
-	defer r.MultipartForm.RemoveAll()
 
-	title := strings.TrimSpace(r.FormValue("title"))
-	description := strings.TrimSpace(r.FormValue("description"))
-	content := strings.TrimSpace(r.FormValue("content"))
-	isDraft := r.FormValue("isDraft") == "true"
-	userID := strings.TrimSpace(r.FormValue("userId"))
+	gist, err := gistFromForm(r, form)
+	if err != nil {
+		h.discardAttachment(r.Context(), form.attachment)
+		h.respondError(w, err)
+		return
+	}
 
-	if title == "" {
-		h.respondError(w, apperror.Validation("title is required"))
+	if err := h.createGist(r.Context(), gist, form.attachment); err != nil {
+		h.respondError(w, err)
 		return
 	}
+
+	h.respondJSON(w, http.StatusCreated, h.gistToResponse(gist))
+}
+
+// gistFromForm validates a create request and builds the gist it
+// describes, without its attachment.
+func gistFromForm(r *http.Request, form *createForm) (*model.Gist, error) {
+	title := strings.TrimSpace(form.values.Get("title"))
+	description := strings.TrimSpace(form.values.Get("description"))
+	content := strings.TrimSpace(form.values.Get("content"))
+	isDraft := form.values.Get("isDraft") == "true"
+	userID := strings.TrimSpace(form.values.Get("userId"))
+	if tokenUserID, ok := tokenUser(r.Context()); ok {
+		userID = tokenUserID
+	}
+	languageName := strings.TrimSpace(form.values.Get("language"))
+	visibilityName := strings.TrimSpace(form.values.Get("visibility"))
+	expiresIn := strings.TrimSpace(form.values.Get("expiresIn"))
+
+	var tagNames []string
+	for _, v := range form.values["tags"] {
+		tagNames = append(tagNames, strings.Split(v, ",")...)
+	}
+	tags, err := model.NormalizeTags(tagNames)
+	if err != nil {
+		return nil, apperror.Validation(err.Error())
+	}
+
+	if title == "" {
+		return nil, apperror.Validation("title is required")
+	}
 	if content == "" {
-		h.respondError(w, apperror.Validation("content is required"))
-		return
+		return nil, apperror.Validation("content is required")
 	}
 	if len(content) > maxContentSize {
-		h.respondError(w, apperror.Validation("content exceeds maximum size"))
-		return
+		return nil, apperror.Validation("content exceeds maximum size")
+	}
+
+	visibility := model.VisibilityPublic
+	if visibilityName != "" {
+		v, ok := model.ParseVisibility(visibilityName)
+		if !ok {
+			return nil, apperror.Validation("visibility must be public, unlisted or private")
+		}
+		visibility = v
+	}
+	// Only the token owner can see a private gist, so one attributed by
+	// the userId field alone would be lost to everyone.
+	if _, ok := tokenUser(r.Context()); visibility == model.VisibilityPrivate && !ok {
+		return nil, apperror.Validation("private gists require an API token")
+	}
+
+	var expiresAt time.Time
+	if expiresIn != "" {
+		d, err := time.ParseDuration(expiresIn)
+		if err != nil || d < minExpiry || d > maxExpiry {
+			return nil, apperror.Validation("expiresIn must be a duration between 1m and 8760h")
+		}
+		expiresAt = time.Now().UTC().Add(d)
+	}
+
+	var fileName string
+	if form.attachment != nil {
+		fileName = form.attachment.name
+	}
+	lang, err := resolveLanguage(languageName, fileName, content)
+	if err != nil {
+		return nil, err
 	}
 
-	gist := model.NewGist(title, description, content, isDraft)
+	gist := model.NewGist(title, description, content, isDraft).
+		WithLanguage(lang).
+		WithVisibility(visibility).
+		WithTags(tags).
+		WithExpiry(expiresAt)
 	if userID != "" {
 		gist.WithUser(userID)
 	}
+	return gist, nil
+}
+
+// createGist saves a new gist along with its staged attachment, if any.
+// Either both are saved or neither is, and the staged file is gone
+// afterwards.
+func (h *Handler) createGist(ctx context.Context, gist *model.Gist, staged *stagedAttachment) error {
+	if staged != nil {
+		if err := h.createGistWithAttachment(ctx, gist, staged); err != nil {
+			return err
+		}
+	} else {
+		id, err := h.repo.Create(ctx, gist)
+		if err != nil {
+			return err
+		}
+		gist.ID = id
+	}
+
+	h.indexGist(gist)
+	h.notify(ctx, model.EventGistCreated, gist)
+	return nil
+}
+
+// createGistWithAttachment moves a staged attachment under a new gist ID
+// and then saves the gist referring to it in a single write. Files left
+// behind by a failed step are removed again; leftovers of a crash are
+// found by reconciliation.
+func (h *Handler) createGistWithAttachment(ctx context.Context, gist *model.Gist, staged *stagedAttachment) error {
+	id := h.repo.NewID()
+	info, err := h.storage.Move(ctx, stagingStorageID, staged.path, id, staged.name)
+	if err != nil {
+		h.discardAttachment(ctx, staged)
+		return attachmentError(err)
+	}
+
+	gist.ID = id
+	gist.WithFile(info.FileName, info.FileURL, info.PublicFileURL).
+		WithFileChecksum(staged.checksum)
+	if _, err := h.repo.Create(ctx, gist); err != nil {
+		h.removeFile(ctx, id, staged.name)
+		gist.ID = ""
+		return err
+	}
+
+	return nil
+}
+
+// Update handles PUT /gist/:id
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return
+	}
 
-	id, err := h.repo.Create(r.Context(), gist)
+	gist, err := h.getOwnGist(r, id, "edit")
 	if err != nil {
 		h.respondError(w, err)
 		return
 	}
-	gist.ID = id
 
-	file, header, err := r.FormFile("file")
-	if err == nil {
-		defer file.Close()
-		
-		if header.Size > maxFileSize {
-			h.respondError(w, apperror.Validation("file exceeds maximum size"))
-			return
+	var req UpdateGistRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpdateSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+
+	// An If-Match header makes the update conditional on the version the
+	// client last saw, so that it cannot overwrite newer edits.
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch != "" && !etagMatches(ifMatch, gistETag(gist)) {
+		h.respondError(w, apperror.PreconditionFailed("the gist was modified; fetch it and try again"))
+		return
+	}
+
+	err = h.updateGist(r.Context(), gist, ifMatch != "", func(gist *model.Gist) error {
+		return applyGistUpdate(gist, req)
+	})
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	resp := h.gistToResponse(gist)
+	resp.Stars = h.starCount(r.Context(), gist.ID)
+
+	w.Header().Set("ETag", gistETag(gist))
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// Delete handles DELETE /gist/:id for the owner of the API token.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return
+	}
+
+	gist, err := h.getOwnGist(r, id, "delete")
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	if err := h.deleteGist(r.Context(), gist); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getOwnGist loads a gist that the caller is about to change, failing
+// unless the request is authenticated with an API token of its owner.
+func (h *Handler) getOwnGist(r *http.Request, id, action string) (*model.Gist, error) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		return nil, err
+	}
+
+	gist, err := h.repo.Get(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if !gist.CanView(callerID) || gist.Expired(time.Now()) {
+		return nil, apperror.NotFound("gist")
+	}
+	if gist.UserID == "" || gist.UserID != callerID {
+		return nil, apperror.Forbidden("only the owner can " + action + " this gist")
+	}
+	return gist, nil
+}
+
+// updateGist applies a change to a gist and saves it, keeping its history,
+// cache entry and search index entry in step. A conditional update fails
+// when the gist changed since it was loaded.
+func (h *Handler) updateGist(ctx context.Context, gist *model.Gist, conditional bool, apply func(*model.Gist) error) error {
+	// Recording the previous state first keeps the Git history of gists
+	// that have not been edited since revisions were introduced.
+	h.recordHistory(ctx, gist)
+
+	loadedUpdatedAt := gist.UpdatedAt
+	if err := apply(gist); err != nil {
+		return err
+	}
+	gist.UpdatedAt = time.Now().UTC()
+
+	var err error
+	if conditional {
+		err = h.repo.UpdateIfUnchanged(ctx, gist, loadedUpdatedAt)
+	} else {
+		err = h.repo.Update(ctx, gist)
+	}
+	if err != nil {
+		return err
+	}
+
+	h.recordHistory(ctx, gist)
+	h.cache.Delete(gist.ID)
+	h.indexGist(gist)
+	h.notify(ctx, model.EventGistUpdated, gist)
+	return nil
+}
+
+// deleteGist removes a gist and everything stored with it.
+func (h *Handler) deleteGist(ctx context.Context, gist *model.Gist) error {
+	revisions, err := h.repo.ListRevisions(ctx, gist.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := h.repo.Delete(ctx, gist.ID); err != nil {
+		return err
+	}
+
+	// The document is gone at this point, so a leftover attachment is only
+	// logged rather than failing the request.
+	if gist.FileName != "" {
+		if err := h.storage.Delete(ctx, gist.ID, gist.FileName); err != nil {
+			h.errorLog.Printf("failed to delete file of gist %s: %v", gist.ID, err)
+		}
+	}
+	h.deleteRevisionObjects(ctx, gist.ID, revisions)
+
+	h.cache.Delete(gist.ID)
+	if err := h.index.Delete(gist.ID); err != nil {
+		h.errorLog.Printf("failed to remove gist %s from index: %v", gist.ID, err)
+	}
+	h.notify(ctx, model.EventGistDeleted, gist)
+	return nil
+}
+
+// applyGistUpdate validates and applies the fields present in req. A
+// detected language is re-detected when the content changes, while an
+// explicitly chosen one is kept.
+func applyGistUpdate(gist *model.Gist, req UpdateGistRequest) error {
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return apperror.Validation("title is required")
+		}
+		gist.Title = title
+	}
+
+	if req.Description != nil {
+		gist.Description = strings.TrimSpace(*req.Description)
+	}
+
+	if req.Content != nil {
+		content := strings.TrimSpace(*req.Content)
+		if content == "" {
+			return apperror.Validation("content is required")
 		}
+		if len(content) > maxContentSize {
+			return apperror.Validation("content exceeds maximum size")
+		}
+
+		detected := gist.Language == "" || gist.Language == language.Detect(gist.FileName, gist.Content)
+		gist.SetContent(content)
+		if detected && req.Language == nil {
+			gist.Language = language.Detect(gist.FileName, gist.Content)
+		}
+	}
 
-		fileInfo, err := h.storage.Upload(r.Context(), id, header.Filename, file, header.Size)
+	if req.Language != nil {
+		lang, err := resolveLanguage(strings.TrimSpace(*req.Language), gist.FileName, gist.Content)
 		if err != nil {
-			h.errorLog.Printf("file upload failed: %v", err)
-		} else {
-			gist.WithFile(fileInfo.FileName, fileInfo.FileURL, fileInfo.PublicFileURL)
-			if err := h.repo.Update(r.Context(), gist); err != nil {
-				h.errorLog.Printf("failed to update gist with file info: %v", err)
-			}
+			return err
 		}
+		gist.Language = lang
 	}
 
-	h.respondJSON(w, http.StatusCreated, h.gistToResponse(gist))
+	if req.IsDraft != nil {
+		gist.IsDraft = *req.IsDraft
+	}
+
+	if req.Visibility != nil {
+		visibility, ok := model.ParseVisibility(strings.TrimSpace(*req.Visibility))
+		if !ok {
+			return apperror.Validation("visibility must be public, unlisted or private")
+		}
+		gist.Visibility = visibility
+	}
+
+	if req.Tags != nil {
+		tags, err := model.NormalizeTags(*req.Tags)
+		if err != nil {
+			return apperror.Validation(err.Error())
+		}
+		gist.Tags = tags
+	}
+
+	return nil
 }
 
 // ListByUser handles GET /gist/user-gists
+//
+// Without a userId parameter the gists of the API token's owner are
+// listed. Only the token owner sees their unlisted and private gists;
+// anyone else gets the public ones.
 func (h *Handler) ListByUser(w http.ResponseWriter, r *http.Request) {
 	userID := strings.TrimSpace(r.URL.Query().Get("userId"))
+	if userID == "" {
+		userID, _ = tokenUser(r.Context())
+	}
 
 	if userID == "" {
 		h.respondError(w, apperror.BadRequest("userId is required"))
 		return
 	}
 
-	gists, err := h.repo.ListByUser(r.Context(), userID, 100)
+	opts, err := parseListOptions(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	fields, err := parseFields(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	opts.Fields = documentFields(fields)
+
+	if userID != h.callerID(r) {
+		if opts.Visibility != "" && opts.Visibility != model.VisibilityPublic {
+			h.respondJSON(w, http.StatusOK, GistListResponse{Gists: []GistFields{}})
+			return
+		}
+		opts.Visibility = model.VisibilityPublic
+	}
+
+	gists, next, err := h.repo.ListByUser(r.Context(), userID, opts)
 	if err != nil {
 		h.respondError(w, err)
 		return
 	}
+	gists = dropExpired(gists)
 
-	responses := make([]GistResponse, len(gists))
+	responses := make([]GistFields, len(gists))
 	for i, g := range gists {
-		responses[i] = h.gistToResponse(g)
+		responses[i] = selectFields(g, fields)
+	}
+
+	h.respondJSON(w, http.StatusOK, GistListResponse{
+		Gists:      responses,
+		NextCursor: next,
+	})
+}
+
+// dropExpired removes the gists that expired by now from a listing. They
+// stay stored until quickgist-admin purge-expired deletes them, so pages
+// can come out shorter than requested.
+func dropExpired(gists []*model.Gist) []*model.Gist {
+	now := time.Now()
+	live := gists[:0]
+	for _, g := range gists {
+		if !g.Expired(now) {
+			live = append(live, g)
+		}
+	}
+	return live
+}
+
+// parseListOptions reads pagination, filter and sort parameters shared by
+// the gist listing endpoints.
+func parseListOptions(r *http.Request) (repository.ListOptions, error) {
+	params := r.URL.Query()
+	var opts repository.ListOptions
+
+	limit, err := optionalPositiveInt(params.Get("limit"))
+	if err != nil {
+		return opts, apperror.BadRequest("limit must be a positive integer")
+	}
+	opts.Limit = limit
+	opts.Cursor = strings.TrimSpace(params.Get("cursor"))
+
+	if opts.Tag, err = model.NormalizeTag(params.Get("tag")); err != nil {
+		return opts, apperror.Validation(err.Error())
+	}
+
+	if lang := strings.TrimSpace(params.Get("language")); lang != "" {
+		normalized, ok := language.Normalize(lang)
+		if !ok {
+			return opts, apperror.Validation("unsupported language")
+		}
+		opts.Language = normalized
 	}
 
-	h.respondJSON(w, http.StatusOK, responses)
+	switch params.Get("isDraft") {
+	case "":
+	case "true", "false":
+		isDraft := params.Get("isDraft") == "true"
+		opts.IsDraft = &isDraft
+	default:
+		return opts, apperror.Validation("isDraft must be true or false")
+	}
+
+	if v := strings.TrimSpace(params.Get("visibility")); v != "" {
+		visibility, ok := model.ParseVisibility(v)
+		if !ok {
+			return opts, apperror.Validation("visibility must be public, unlisted or private")
+		}
+		opts.Visibility = visibility
+	}
+
+	if opts.From, err = parseTimeParam(params.Get("from"), false); err != nil {
+		return opts, apperror.Validation("from must be a date or RFC 3339 timestamp")
+	}
+	if opts.To, err = parseTimeParam(params.Get("to"), true); err != nil {
+		return opts, apperror.Validation("to must be a date or RFC 3339 timestamp")
+	}
+
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		opts.Ascending = true
+	default:
+		return opts, apperror.Validation("order must be asc or desc")
+	}
+
+	return opts, nil
+}
+
+// getGist loads a gist by ID, serving it from the cache when possible.
+func (h *Handler) getGist(ctx context.Context, id string) (*model.Gist, error) {
+	if cached, ok := h.cache.Get(id); ok {
+		return cached, nil
+	}
+
+	gist, err := h.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	h.cache.Set(id, gist, cacheTTL)
+	return gist, nil
+}
+
+// getVisibleGist loads a gist by ID, reporting it as not found when the
+// caller is not allowed to see it.
+func (h *Handler) getVisibleGist(r *http.Request, id string) (*model.Gist, error) {
+	gist, err := h.getGist(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !gist.CanView(h.callerID(r)) || gist.Expired(time.Now()) {
+		return nil, apperror.NotFound("gist")
+	}
+
+	return gist, nil
+}
+
+// setGistCacheControl lets shared caches keep a response about a public
+// gist with the given policy. Unlisted and private gists are not cached,
+// since their responses depend on who asks and must not outlive a change
+// of visibility.
+func setGistCacheControl(w http.ResponseWriter, gist *model.Gist, public string) {
+	if gist.Visibility != model.VisibilityPublic {
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Add("Vary", "Authorization")
+		return
+	}
+	w.Header().Set("Cache-Control", public)
+}
+
+// indexGist refreshes the search index entry for a gist. Failures are only
+// logged so that they never fail the write that triggered them.
+func (h *Handler) indexGist(g *model.Gist) {
+	if err := h.index.Index(g); err != nil {
+		h.errorLog.Printf("failed to index gist %s: %v", g.ID, err)
+	}
+}
+
+// notify queues webhook deliveries for an event on a gist. Only events the
+// owner caused with their API token are delivered: anonymous gists can
+// name any user as their owner, and that must not reach the user's hooks.
+// Failures are only logged so that they never fail the write that
+// triggered them.
+func (h *Handler) notify(ctx context.Context, event model.WebhookEvent, g *model.Gist) {
+	if callerID, ok := tokenUser(ctx); g.UserID == "" || !ok || callerID != g.UserID {
+		return
+	}
+
+	payload := WebhookPayload{
+		Event:     string(event),
+		Timestamp: time.Now().UTC(),
+		Gist:      selectFields(g, summaryFields),
+	}
+
+	if err := h.webhooks.Enqueue(ctx, g.UserID, event, payload); err != nil {
+		h.errorLog.Printf("failed to queue %s webhooks for gist %s: %v", event, g.ID, err)
+	}
+}
+
+// resolveLanguage validates a user-supplied language override, falling back
+// to detection from the attachment name and content when none is given.
+func resolveLanguage(override, fileName, content string) (string, error) {
+	if override == "" {
+		return language.Detect(fileName, content), nil
+	}
+
+	lang, ok := language.Normalize(override)
+	if !ok {
+		return "", apperror.Validation("unsupported language")
+	}
+	return lang, nil
 }
 
 func (h *Handler) gistToResponse(g *model.Gist) GistResponse {
@@ -137,17 +643,58 @@ func (h *Handler) gistToResponse(g *model.Gist) GistResponse {
 		Title:       g.Title,
 		Description: g.Description,
 		Content:     g.Content,
+		Language:    g.ResolvedLanguage(),
+		Tags:        g.Tags,
 		IsDraft:     g.IsDraft,
+		Visibility:  string(g.Visibility),
 		CreatedAt:   g.CreatedAt,
 		UserID:      g.UserID,
 		FileName:    g.FileName,
+		FileURL:     gistFileURL(g),
+		FileSHA256:  g.FileSHA256,
 	}
 
-	if g.PublicFileURL != "" {
-		resp.FileURL = g.PublicFileURL
-	} else if g.FileName != "" {
-		resp.FileURL = fmt.Sprintf(fileURLPattern, g.ID, url.PathEscape(g.FileName))
+	// Counts that are still buffered are added so that a client sees its
+	// own views immediately.
+	pending := h.counters.Pending(g.ID)
+	resp.ViewCount = g.ViewCount + pending.Views
+	resp.DownloadCount = g.DownloadCount + pending.Downloads
+
+	if !g.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &g.UpdatedAt
+	}
+	if !g.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &g.ExpiresAt
 	}
 
 	return resp
 }
+
+// gistETag identifies the version of a gist by its last modification,
+// which is stored with microsecond precision.
+func gistETag(g *model.Gist) string {
+	sum := sha256.Sum256([]byte(g.ID + "@" + strconv.FormatInt(gistModified(g).UnixMicro(), 10)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches evaluates an If-Match header against the current ETag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// gistFileURL returns the public URL of the gist attachment, if any.
+func gistFileURL(g *model.Gist) string {
+	if g.PublicFileURL != "" {
+		return g.PublicFileURL
+	}
+	if g.FileName != "" {
+		return fmt.Sprintf(fileURLPattern, g.ID, url.PathEscape(g.FileName))
+	}
+	return ""
+}
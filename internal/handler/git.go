@@ -0,0 +1,488 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/git"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	// maxUploadPackRequest bounds the negotiation a client can send. A gist
+	// has a single branch, so requests are only a few lines long.
+	maxUploadPackRequest = 1 << 20
+
+	// maxPushSize bounds a receive-pack request, pack included.
+	maxPushSize = 32 << 20
+
+	// maxPushCommits bounds the commits a single push can add.
+	maxPushCommits = 100
+
+	// maxPushObjects bounds the objects of a pushed pack: each commit adds
+	// itself, a tree and the gist's files.
+	maxPushObjects = maxPushCommits * (2 + maxGistFiles)
+
+	// maxPushInflated bounds the memory the objects of a push take up once
+	// inflated.
+	maxPushInflated = 4 * maxPushSize
+
+	// maxGistFiles is the number of files a gist repository can hold: the
+	// content and an optional attachment.
+	maxGistFiles = 2
+
+	// regularFileMode is the only tree entry mode a gist can hold.
+	regularFileMode = "100644"
+)
+
+// authChallenge asks git clients for credentials, which are an API token
+// used as the password.
+const authChallenge = `Basic realm="QuickGist", charset="UTF-8"`
+
+// pushRejection is a reason to refuse a ref update, shown to the user in
+// full and reported to git in short.
+type pushRejection struct {
+	reason  string
+	message string
+}
+
+func (e *pushRejection) Error() string {
+	return e.message
+}
+
+func rejectPush(reason, format string, args ...interface{}) *pushRejection {
+	return &pushRejection{reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// GitInfoRefs handles GET /gist/:id.git/info/refs
+func (h *Handler) GitInfoRefs(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service != git.UploadPack && service != git.ReceivePack {
+		// The dumb protocol would need a static repository layout.
+		h.respondError(w, apperror.Forbidden("only the smart HTTP protocol is supported"))
+		return
+	}
+
+	gist, err := h.gitGist(r, service)
+	if err != nil {
+		h.respondGitError(w, err)
+		return
+	}
+
+	_, head, err := h.gitRepository(r.Context(), gist)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-"+service+"-advertisement")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	advertise := git.AdvertiseUploadPack
+	if service == git.ReceivePack {
+		advertise = git.AdvertiseReceivePack
+	}
+	if err := advertise(w, head); err != nil {
+		h.errorLog.Printf("error advertising refs: %v", err)
+	}
+}
+
+// GitUploadPack handles POST /gist/:id.git/git-upload-pack
+func (h *Handler) GitUploadPack(w http.ResponseWriter, r *http.Request) {
+	gist, err := h.gitGist(r, git.UploadPack)
+	if err != nil {
+		h.respondGitError(w, err)
+		return
+	}
+	h.allowTransfer(w)
+
+	store, _, err := h.gitRepository(r.Context(), gist)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	body, err := gitRequestBody(w, r, maxUploadPackRequest)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := git.ServeUploadPack(w, body, store); err != nil {
+		if errors.Is(err, git.ErrProbe) {
+			return
+		}
+		// The response has started, so errors can only be reported in-band.
+		if !errors.Is(err, git.ErrNotOurRef) {
+			h.errorLog.Printf("error serving upload-pack: %v", err)
+		}
+		_ = git.WriteError(w, git.UploadPack, err)
+	}
+}
+
+// GitReceivePack handles POST /gist/:id.git/git-receive-pack. A push may
+// only fast-forward the main branch; each pushed commit becomes a revision
+// and the last one the new state of the gist.
+func (h *Handler) GitReceivePack(w http.ResponseWriter, r *http.Request) {
+	gist, err := h.gitGist(r, git.ReceivePack)
+	if err != nil {
+		h.respondGitError(w, err)
+		return
+	}
+	h.allowTransfer(w)
+
+	history, err := h.gistHistory(r.Context(), gist)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	store, head, err := h.buildRepository(r.Context(), gist.ID, history)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	body, err := gitRequestBody(w, r, maxPushSize)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	defer body.Close()
+
+	reader := bufio.NewReader(body)
+	push, err := git.ReadPushCommands(reader)
+	if errors.Is(err, git.ErrProbe) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		h.respondError(w, apperror.BadRequest("invalid receive-pack request"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-git-receive-pack-result")
+	w.Header().Set("Cache-Control", "no-cache")
+	report := push.Report(w)
+
+	limits := git.PackLimits{
+		MaxPackSize:   maxPushSize,
+		MaxObjects:    maxPushObjects,
+		MaxObjectSize: maxFileSize,
+		MaxTotalSize:  maxPushInflated,
+	}
+	if err := git.ReadPack(reader, store, limits); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			_ = report.Message("error: pushes are limited to %d MB", maxPushSize>>20)
+			err = errors.New("push too large")
+		}
+		if err := report.Finish(push.Commands, err, nil); err != nil {
+			h.errorLog.Printf("error reporting push to gist %s: %v", gist.ID, err)
+		}
+		return
+	}
+
+	refErrors := make(map[string]string)
+	for _, cmd := range push.Commands {
+		err := h.applyPush(r.Context(), gist, history, store, head, cmd, h.callerID(r))
+		if err == nil {
+			continue
+		}
+
+		var rejection *pushRejection
+		if !errors.As(err, &rejection) {
+			h.errorLog.Printf("error applying push to gist %s: %v", gist.ID, err)
+			rejection = rejectPush("internal error", "error: the push could not be saved")
+		}
+		_ = report.Message("error: %s", rejection.message)
+		refErrors[cmd.Ref] = rejection.reason
+	}
+
+	if err := report.Finish(push.Commands, nil, refErrors); err != nil {
+		h.errorLog.Printf("error reporting push to gist %s: %v", gist.ID, err)
+	}
+}
+
+// applyPush validates a ref update, records the pushed commits as
+// revisions and updates the gist to the last of them.
+func (h *Handler) applyPush(
+	ctx context.Context,
+	gist *model.Gist,
+	history []historyEntry,
+	store *git.Store,
+	head git.Hash,
+	cmd git.Command,
+	callerID string,
+) error {
+	switch {
+	case cmd.Ref != git.DefaultBranch:
+		return rejectPush("only main can be pushed", "%s cannot be pushed; gists only have %s", cmd.Ref, git.DefaultBranch)
+	case cmd.New.IsZero():
+		return rejectPush("deletion prohibited", "%s cannot be deleted", cmd.Ref)
+	case cmd.Old != head:
+		return rejectPush("fetch first", "the gist changed since it was fetched; pull and push again")
+	case cmd.New == head:
+		return nil
+	}
+
+	commits, err := git.FastForward(store, head, cmd.New, maxPushCommits)
+	switch {
+	case errors.Is(err, git.ErrTooManyCommits):
+		return rejectPush("too many commits", "a push can add at most %d commits", maxPushCommits)
+	case errors.Is(err, git.ErrMergeCommit):
+		return rejectPush("merge commit", "merge commits are not supported; rebase onto main instead")
+	case err != nil:
+		return rejectPush("non-fast-forward", "force pushes that rewrite the gist history are not allowed")
+	}
+
+	stored := make(map[string]bool)
+	for _, entry := range history {
+		stored[entry.revision.AttachmentHash] = true
+	}
+
+	base := history[len(history)-1].revision
+	prev := base
+	entries := history
+	var attachment []byte
+	for _, hash := range commits {
+		entry, err := pushedRevision(store, hash, prev, stored, callerID)
+		if err != nil {
+			return err
+		}
+		entry.revision.GistID = gist.ID
+		entry.revision.Number = prev.Number + 1
+
+		attachment = entry.attachment
+		if stored[entry.revision.AttachmentHash] {
+			entry.attachment = nil
+		}
+		stored[entry.revision.AttachmentHash] = true
+
+		entries = append(entries, entry)
+		prev = entry.revision
+	}
+
+	// The live attachment is only rewritten when the push changed it.
+	if prev.AttachmentName == base.AttachmentName && prev.AttachmentHash == base.AttachmentHash {
+		attachment = nil
+	}
+
+	if err := h.saveHistory(ctx, gist.ID, entries); err != nil {
+		if apperror.Is(err, apperror.CodeConflict) {
+			return rejectPush("fetch first", "the gist changed during the push; pull and push again")
+		}
+		return err
+	}
+
+	if err := h.updateGistFiles(ctx, gist, prev, attachment); err != nil {
+		return err
+	}
+
+	h.cache.Delete(gist.ID)
+	h.indexGist(gist)
+	h.notify(ctx, model.EventGistUpdated, gist)
+	return nil
+}
+
+// pushedRevision turns a pushed commit into a revision, checking that its
+// tree is something a gist can hold. Attachments already in the gist's
+// history, listed in stored by hash, are kept whatever their size, since
+// Create accepts larger ones than a push can add.
+func pushedRevision(store *git.Store, hash git.Hash, prev *model.Revision, stored map[string]bool, callerID string) (historyEntry, error) {
+	commit, err := store.Commit(hash)
+	if err != nil {
+		return historyEntry{}, err
+	}
+	entries, err := store.Tree(commit.Tree)
+	if err != nil {
+		return historyEntry{}, err
+	}
+
+	short := hash.String()[:7]
+	for _, e := range entries {
+		if e.Mode != regularFileMode {
+			return historyEntry{}, rejectPush("unsupported file",
+				"%s in commit %s is not a regular file; directories, symlinks and executables are not supported", e.Name, short)
+		}
+	}
+	if len(entries) > maxGistFiles {
+		return historyEntry{}, rejectPush("too many files",
+			"commit %s has %d files; a gist holds its content and at most one attachment", short, len(entries))
+	}
+
+	raw, _ := store.Get(hash)
+	rev := &model.Revision{
+		UserID:    callerID,
+		Message:   commit.Message,
+		Commit:    string(raw.Data),
+		CreatedAt: time.Now().UTC(),
+	}
+	entry := historyEntry{revision: rev}
+
+	for _, e := range entries {
+		content, err := store.Blob(e.Hash)
+		if err != nil {
+			return historyEntry{}, err
+		}
+
+		if e.Name == prev.ContentFileName {
+			if len(content) > maxContentSize {
+				return historyEntry{}, rejectPush("file too large",
+					"%s in commit %s exceeds the %d MB content limit", e.Name, short, maxContentSize>>20)
+			}
+			if !utf8.Valid(content) || strings.TrimSpace(string(content)) == "" {
+				return historyEntry{}, rejectPush("invalid content",
+					"%s in commit %s must be non-empty UTF-8 text", e.Name, short)
+			}
+			rev.ContentFileName = e.Name
+			rev.Content = string(content)
+			continue
+		}
+
+		if !stored[e.Hash.String()] && len(content) > maxFileSize {
+			return historyEntry{}, rejectPush("file too large",
+				"%s in commit %s exceeds the %d MB attachment limit", e.Name, short, maxFileSize>>20)
+		}
+		if !safeFilenamePattern.MatchString(e.Name) {
+			return historyEntry{}, rejectPush("invalid file name",
+				"%s in commit %s may only contain letters, digits, dots, dashes and underscores", e.Name, short)
+		}
+		rev.AttachmentName = e.Name
+		rev.AttachmentHash = e.Hash.String()
+		entry.attachment = content
+	}
+
+	if rev.ContentFileName == "" {
+		return historyEntry{}, rejectPush("content file missing",
+			"commit %s removes or renames %s, which holds the gist content", short, prev.ContentFileName)
+	}
+
+	return entry, nil
+}
+
+// updateGistFiles brings the gist and its stored attachment in line with
+// a pushed revision. attachment is nil when the stored one is current.
+func (h *Handler) updateGistFiles(ctx context.Context, gist *model.Gist, rev *model.Revision, attachment []byte) error {
+	oldName := ""
+	if gist.FileName != "" {
+		oldName = path.Base(gist.FileName)
+	}
+
+	gist.SetContent(rev.Content)
+	gist.UpdatedAt = time.Now().UTC()
+
+	if attachment != nil {
+		info, err := h.storage.Upload(ctx, gist.ID, rev.AttachmentName, bytes.NewReader(attachment), int64(len(attachment)))
+		if err != nil {
+			return err
+		}
+		gist.WithFile(info.FileName, info.FileURL, info.PublicFileURL)
+	}
+	if rev.AttachmentName == "" {
+		gist.WithFile("", "", "")
+	}
+
+	if err := h.repo.Update(ctx, gist); err != nil {
+		return err
+	}
+
+	// The old attachment is only removed once the gist no longer refers to
+	// it; its content stays available through the revision copies.
+	if oldName != "" && oldName != rev.AttachmentName {
+		if err := h.storage.Delete(ctx, gist.ID, oldName); err != nil {
+			h.errorLog.Printf("failed to delete old file of gist %s: %v", gist.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// gitGist loads the gist named in the URL for a Git service. Pushing
+// requires the owner to authenticate with an API token.
+func (h *Handler) gitGist(r *http.Request, service string) (*model.Gist, error) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		return nil, apperror.BadRequest("gist ID is required")
+	}
+
+	if service != git.ReceivePack {
+		return h.getVisibleGist(r, id)
+	}
+
+	callerID, ok := tokenUser(r.Context())
+	if !ok {
+		return nil, apperror.Unauthorized("pushing requires an API token")
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		return nil, err
+	}
+	if gist.UserID == "" || gist.UserID != callerID {
+		return nil, apperror.Forbidden("only the owner can push to this gist")
+	}
+
+	return gist, nil
+}
+
+// gitRepository synthesizes the Git repository of a gist, returning its
+// objects and the head commit.
+func (h *Handler) gitRepository(ctx context.Context, gist *model.Gist) (*git.Store, git.Hash, error) {
+	history, err := h.gistHistory(ctx, gist)
+	if err != nil {
+		return nil, git.Hash{}, err
+	}
+	return h.buildRepository(ctx, gist.ID, history)
+}
+
+func (h *Handler) buildRepository(ctx context.Context, gistID string, history []historyEntry) (*git.Store, git.Hash, error) {
+	snapshots, err := h.historySnapshots(ctx, gistID, history)
+	if err != nil {
+		return nil, git.Hash{}, err
+	}
+
+	store := git.NewStore()
+	head := git.BuildHistory(store, snapshots)
+	return store, head, nil
+}
+
+// respondGitError responds to a failed Git request, asking for
+// credentials when they are missing so that git prompts for a token.
+func (h *Handler) respondGitError(w http.ResponseWriter, err error) {
+	if apperror.Is(err, apperror.CodeUnauthorized) {
+		w.Header().Set("WWW-Authenticate", authChallenge)
+	}
+	h.respondError(w, err)
+}
+
+// gitRequestBody limits the body of a Git request and undoes the gzip
+// encoding git uses for large requests.
+func gitRequestBody(w http.ResponseWriter, r *http.Request, limit int64) (io.ReadCloser, error) {
+	body := http.MaxBytesReader(w, r.Body, limit)
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		return body, nil
+	}
+
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, apperror.BadRequest("invalid gzip body")
+	}
+	// The limit also applies after decompression.
+	return http.MaxBytesReader(w, gz, limit), nil
+}
@@ -0,0 +1,523 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/language"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+// The GitHub compatibility API maps GitHub's files onto QuickGist's model:
+// the first file by name holds the gist content and names its title, and
+// a second file becomes the attachment. Secret gists are unlisted.
+const (
+	githubAPIPrefix      = "/api/gh"
+	githubDefaultPerPage = 30
+	maxGitHubRequestSize = maxFileSize + 64<<10
+)
+
+// githubListFields are the response fields needed to describe a gist in
+// a listing, which leaves out its content.
+var githubListFields = []string{
+	"title",
+	"description",
+	"size",
+	"language",
+	"visibility",
+	"createdAt",
+	"updatedAt",
+	"userId",
+	"fileName",
+	"fileURL",
+}
+
+// GitHubListGists handles GET /api/gh/gists
+func (h *Handler) GitHubListGists(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.githubCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.githubList(w, r, callerID, repository.ListOptions{})
+}
+
+// GitHubUserGists handles GET /api/gh/users/:userId/gists
+func (h *Handler) GitHubUserGists(w http.ResponseWriter, r *http.Request) {
+	userID := strings.TrimSpace(chi.URLParam(r, "userId"))
+	if userID == "" {
+		h.respondError(w, apperror.BadRequest("user ID is required"))
+		return
+	}
+
+	isDraft := false
+	h.githubList(w, r, userID, repository.ListOptions{
+		IsDraft:    &isDraft,
+		Visibility: model.VisibilityPublic,
+	})
+}
+
+// GitHubGetGist handles GET /api/gh/gists/:id
+func (h *Handler) GitHubGetGist(w http.ResponseWriter, r *http.Request) {
+	gist, err := h.getVisibleGist(r, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.githubGist(r, gist, true))
+}
+
+// GitHubCreateGist handles POST /api/gh/gists
+func (h *Handler) GitHubCreateGist(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.githubCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	var req GitHubCreateRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxGitHubRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+
+	names := make([]string, 0, len(req.Files))
+	for name, f := range req.Files {
+		if f == nil || f.Content == nil || strings.TrimSpace(*f.Content) == "" {
+			h.respondError(w, apperror.Validation(fmt.Sprintf("file %q has no content", name)))
+			return
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch {
+	case len(names) == 0:
+		h.respondError(w, apperror.Validation("files are required"))
+		return
+	case len(names) > maxGistFiles:
+		h.respondError(w, apperror.Validation("a gist holds its content and at most one attachment"))
+		return
+	}
+
+	fileName := names[0]
+	content := strings.TrimSpace(*req.Files[fileName].Content)
+	if len(content) > maxContentSize {
+		h.respondError(w, apperror.Validation("content exceeds maximum size"))
+		return
+	}
+
+	visibility := model.VisibilityUnlisted
+	if req.Public {
+		visibility = model.VisibilityPublic
+	}
+
+	gist := model.NewGist(fileTitle(fileName), strings.TrimSpace(req.Description), content, false).
+		WithLanguage(language.Detect(fileName, content)).
+		WithVisibility(visibility).
+		WithUser(callerID)
+
+	var staged *stagedAttachment
+	if len(names) == maxGistFiles {
+		name := names[1]
+		data := *req.Files[name].Content
+		if err := validateAttachment(name, len(data)); err != nil {
+			h.respondError(w, err)
+			return
+		}
+		staged, err = h.stageAttachment(r.Context(), name, strings.NewReader(data), maxFileSize)
+		if err != nil {
+			h.respondError(w, err)
+			return
+		}
+	}
+
+	if err := h.createGist(r.Context(), gist, staged); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	resp := h.githubGist(r, gist, true)
+	w.Header().Set("Location", resp.URL)
+	h.respondJSON(w, http.StatusCreated, resp)
+}
+
+// GitHubUpdateGist handles PATCH /api/gh/gists/:id. Files set to null are
+// removed and files under a new name are added as the attachment.
+// Attachments cannot be edited in place.
+func (h *Handler) GitHubUpdateGist(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.githubCaller(r); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	gist, err := h.getOwnGist(r, chi.URLParam(r, "id"), "edit")
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	var req GitHubUpdateRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxGitHubRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+
+	var removed string
+	err = h.updateGist(r.Context(), gist, false, func(gist *model.Gist) error {
+		if req.Description != nil {
+			gist.Description = strings.TrimSpace(*req.Description)
+		}
+
+		var err error
+		removed, err = h.applyGitHubFiles(r.Context(), gist, req.Files)
+		return err
+	})
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	if removed != "" {
+		if err := h.storage.Delete(r.Context(), gist.ID, removed); err != nil {
+			h.errorLog.Printf("failed to delete file of gist %s: %v", gist.ID, err)
+		}
+	}
+
+	h.respondJSON(w, http.StatusOK, h.githubGist(r, gist, true))
+}
+
+// GitHubDeleteGist handles DELETE /api/gh/gists/:id
+func (h *Handler) GitHubDeleteGist(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.githubCaller(r); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	gist, err := h.getOwnGist(r, chi.URLParam(r, "id"), "delete")
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	if err := h.deleteGist(r.Context(), gist); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GitHubStarStatus handles GET /api/gh/gists/:id/star, answering 204 when
+// the caller starred the gist and 404 otherwise.
+func (h *Handler) GitHubStarStatus(w http.ResponseWriter, r *http.Request) {
+	callerID, gist, err := h.githubStarTarget(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	starred, err := h.repo.IsStarred(r.Context(), callerID, gist.ID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if !starred {
+		h.respondError(w, apperror.NotFound("star"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GitHubStar handles PUT /api/gh/gists/:id/star
+func (h *Handler) GitHubStar(w http.ResponseWriter, r *http.Request) {
+	callerID, gist, err := h.githubStarTarget(r)
+	if err == nil {
+		_, err = h.repo.Star(r.Context(), callerID, gist.ID)
+	}
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GitHubUnstar handles DELETE /api/gh/gists/:id/star
+func (h *Handler) GitHubUnstar(w http.ResponseWriter, r *http.Request) {
+	callerID, gist, err := h.githubStarTarget(r)
+	if err == nil {
+		_, err = h.repo.Unstar(r.Context(), callerID, gist.ID)
+	}
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GitHubRaw handles GET /api/gh/gists/:id/raw/:file, serving the content
+// file as text and redirecting to the attachment.
+func (h *Handler) GitHubRaw(w http.ResponseWriter, r *http.Request) {
+	gist, err := h.getVisibleGist(r, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	switch name := chi.URLParam(r, "file"); {
+	case name == gist.ContentFileName():
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, name, gistModified(gist), strings.NewReader(gist.Content))
+	case gist.FileName != "" && name == path.Base(gist.FileName):
+		http.Redirect(w, r, gistFileURL(gist), http.StatusFound)
+	default:
+		h.respondError(w, apperror.NotFound("file"))
+	}
+}
+
+// githubList responds with a page of a user's gists. Pages are linked
+// with cursors in the Link header, which GitHub clients follow.
+func (h *Handler) githubList(w http.ResponseWriter, r *http.Request, userID string, opts repository.ListOptions) {
+	params := r.URL.Query()
+
+	perPage, err := optionalPositiveInt(params.Get("per_page"))
+	if err != nil {
+		h.respondError(w, apperror.BadRequest("per_page must be a positive integer"))
+		return
+	}
+	if perPage == 0 {
+		perPage = githubDefaultPerPage
+	}
+	opts.Limit = perPage
+	opts.Cursor = strings.TrimSpace(params.Get("cursor"))
+	opts.Fields = documentFields(githubListFields)
+
+	gists, next, err := h.repo.ListByUser(r.Context(), userID, opts)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	gists = dropExpired(gists)
+
+	if next != "" {
+		params.Set("cursor", next)
+		params.Set("per_page", fmt.Sprint(perPage))
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?%s>; rel="next"`, h.baseURL(r), r.URL.Path, params.Encode()))
+	}
+
+	resp := make([]GitHubGist, len(gists))
+	for i, g := range gists {
+		resp[i] = h.githubGist(r, g, false)
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// applyGitHubFiles applies the files of a PATCH request and returns the
+// stored name of an attachment that was removed.
+func (h *Handler) applyGitHubFiles(ctx context.Context, gist *model.Gist, files map[string]*GitHubFileInput) (string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	// Removals go first so that an attachment can be replaced in one
+	// request.
+	sort.Slice(names, func(i, j int) bool {
+		if (files[names[i]] == nil) != (files[names[j]] == nil) {
+			return files[names[i]] == nil
+		}
+		return names[i] < names[j]
+	})
+
+	contentName := gist.ContentFileName()
+	var removed string
+
+	for _, name := range names {
+		f := files[name]
+		isAttachment := gist.FileName != "" && name == path.Base(gist.FileName)
+
+		switch {
+		case name == contentName:
+			if f == nil {
+				return "", apperror.Validation("the content file of a gist cannot be removed")
+			}
+			if err := applyGitHubContent(gist, name, f); err != nil {
+				return "", err
+			}
+
+		case isAttachment && f == nil:
+			removed = gist.FileName
+			gist.WithFile("", "", "")
+
+		case isAttachment:
+			return "", apperror.Validation("attachments cannot be edited in place; remove the file and add it again")
+
+		case f == nil:
+			return "", apperror.NotFound(fmt.Sprintf("file %q", name))
+
+		case gist.FileName != "":
+			return "", apperror.Validation("a gist holds its content and at most one attachment")
+
+		default:
+			if f.Filename != nil {
+				name = *f.Filename
+			}
+			if f.Content == nil || *f.Content == "" {
+				return "", apperror.Validation(fmt.Sprintf("file %q has no content", name))
+			}
+			if err := validateAttachment(name, len(*f.Content)); err != nil {
+				return "", err
+			}
+
+			info, err := h.storage.Upload(ctx, gist.ID, name, bytes.NewReader([]byte(*f.Content)), int64(len(*f.Content)))
+			if err != nil {
+				return "", err
+			}
+			gist.WithFile(info.FileName, info.FileURL, info.PublicFileURL)
+		}
+	}
+
+	return removed, nil
+}
+
+// applyGitHubContent updates the content file. Renaming it changes the
+// title, and the language when the new extension is recognized.
+func applyGitHubContent(gist *model.Gist, name string, f *GitHubFileInput) error {
+	req := UpdateGistRequest{Content: f.Content}
+
+	if f.Filename != nil && *f.Filename != name {
+		title := fileTitle(*f.Filename)
+		req.Title = &title
+
+		content := gist.Content
+		if f.Content != nil {
+			content = *f.Content
+		}
+		if lang := language.Detect(*f.Filename, content); lang != "" {
+			req.Language = &lang
+		}
+	}
+
+	return applyGistUpdate(gist, req)
+}
+
+// githubStarTarget resolves the caller and the gist of a star request.
+func (h *Handler) githubStarTarget(r *http.Request) (string, *model.Gist, error) {
+	callerID, err := h.githubCaller(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	gist, err := h.getVisibleGist(r, chi.URLParam(r, "id"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return callerID, gist, nil
+}
+
+// githubCaller returns the owner of the API token the request carries,
+// which GitHub clients send as "Authorization: token ...". It fails with
+// GitHub's message so that clients report it as they would for GitHub.
+func (h *Handler) githubCaller(r *http.Request) (string, error) {
+	callerID := h.callerID(r)
+	if callerID == "" {
+		return "", apperror.Unauthorized("Requires authentication")
+	}
+	return callerID, nil
+}
+
+// githubGist converts a gist to the GitHub shape, with the text of its
+// content file when withContent is set.
+func (h *Handler) githubGist(r *http.Request, g *model.Gist, withContent bool) GitHubGist {
+	base := h.baseURL(r)
+	apiURL := base + githubAPIPrefix + "/gists/" + url.PathEscape(g.ID)
+	gitURL := base + "/gist/" + url.PathEscape(g.ID) + ".git"
+
+	contentName := g.ContentFileName()
+	content := GitHubFile{
+		Filename: contentName,
+		Type:     fileMIMEType(contentName),
+		Language: g.ResolvedLanguage(),
+		RawURL:   apiURL + "/raw/" + url.PathEscape(contentName),
+		Size:     g.Size,
+	}
+	if withContent {
+		content.Content = g.Content
+		content.Size = int64(len(g.Content))
+	}
+
+	resp := GitHubGist{
+		URL:         apiURL,
+		ID:          g.ID,
+		HTMLURL:     h.viewURL(g.ID),
+		GitPullURL:  gitURL,
+		GitPushURL:  gitURL,
+		Files:       map[string]GitHubFile{contentName: content},
+		Public:      g.Visibility == model.VisibilityPublic,
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   gistModified(g),
+		Description: g.Description,
+	}
+
+	if g.FileName != "" {
+		name := path.Base(g.FileName)
+		resp.Files[name] = GitHubFile{
+			Filename: name,
+			Type:     fileMIMEType(name),
+			RawURL:   base + gistFileURL(g),
+		}
+	}
+	if g.UserID != "" {
+		resp.Owner = &GitHubUser{Login: g.UserID}
+	}
+
+	return resp
+}
+
+func validateAttachment(name string, size int) error {
+	if !safeFilenamePattern.MatchString(name) {
+		return apperror.Validation("file names may only contain letters, digits, dots, dashes and underscores")
+	}
+	if size > maxFileSize {
+		return apperror.Validation("file exceeds maximum size")
+	}
+	return nil
+}
+
+// fileTitle derives a gist title from a file name by dropping its
+// extension.
+func fileTitle(name string) string {
+	title := strings.TrimSpace(strings.TrimSuffix(name, path.Ext(name)))
+	if title == "" {
+		return strings.TrimSpace(name)
+	}
+	return title
+}
+
+func fileMIMEType(name string) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "text/plain"
+}
@@ -1,40 +1,123 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"sync"
 
 	"github.com/abhisheksharm-3/quickgist/internal/apperror"
 	"github.com/abhisheksharm-3/quickgist/internal/cache"
+	"github.com/abhisheksharm-3/quickgist/internal/config"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
 	"github.com/abhisheksharm-3/quickgist/internal/repository"
+	"github.com/abhisheksharm-3/quickgist/internal/search"
+	"github.com/abhisheksharm-3/quickgist/internal/stats"
 	"github.com/abhisheksharm-3/quickgist/internal/storage"
+	"github.com/abhisheksharm-3/quickgist/internal/webhook"
 )
 
 // Handler holds dependencies for HTTP handlers.
 type Handler struct {
-	repo     repository.GistRepository
+	config   *config.Config
+	repo     repository.Repository
 	storage  storage.FileStorage
 	cache    cache.Cache
+	index    search.Index
+	counters *stats.Aggregator
+	webhooks *webhook.Dispatcher
 	infoLog  *log.Logger
 	errorLog *log.Logger
+
+	exportCtx     context.Context
+	cancelExports context.CancelFunc
+	exportSlots   chan struct{}
+	exportJobs    sync.WaitGroup
 }
 
 // New creates a new Handler with the given dependencies.
 func New(
-	repo repository.GistRepository,
+	cfg *config.Config,
+	repo repository.Repository,
 	storage storage.FileStorage,
 	cache cache.Cache,
+	index search.Index,
 	infoLog *log.Logger,
 	errorLog *log.Logger,
 ) *Handler {
-	return &Handler{
+	h := &Handler{
+		config:   cfg,
 		repo:     repo,
 		storage:  storage,
 		cache:    cache,
+		index:    index,
 		infoLog:  infoLog,
 		errorLog: errorLog,
 	}
+	h.counters = stats.NewAggregator(stats.SinkFunc(h.flushCounts), cfg.Stats.FlushInterval, errorLog)
+	h.webhooks = webhook.NewDispatcher(repo, cfg.Webhook, errorLog)
+	h.exportCtx, h.cancelExports = context.WithCancel(context.Background())
+	h.exportSlots = make(chan struct{}, maxConcurrentExports)
+
+	return h
+}
+
+// Close flushes state buffered by the handlers. It is called once the
+// server has stopped accepting requests.
+func (h *Handler) Close(ctx context.Context) error {
+	return errors.Join(
+		h.counters.Close(ctx),
+		h.webhooks.Close(ctx),
+		h.closeExports(ctx),
+	)
+}
+
+// flushCounts persists buffered usage counters and evicts the affected
+// gists from the cache so that their new totals become visible.
+func (h *Handler) flushCounts(ctx context.Context, deltas map[string]model.GistCounts) error {
+	err := h.repo.IncrementCounts(ctx, deltas)
+	for id := range deltas {
+		h.cache.Delete(id)
+	}
+	return err
+}
+
+// callerID returns the ID of the user making the request, which is only
+// known when an API token authenticated it. User IDs are public, so one
+// supplied by the client never identifies the caller.
+func (h *Handler) callerID(r *http.Request) string {
+	userID, _ := tokenUser(r.Context())
+	return userID
+}
+
+// requireCaller returns the ID of the user making the request, failing when
+// the request is not authenticated with an API token.
+func (h *Handler) requireCaller(r *http.Request) (string, error) {
+	userID := h.callerID(r)
+	if userID == "" {
+		return "", apperror.Unauthorized("an API token is required")
+	}
+	return userID, nil
+}
+
+// baseURL returns the externally visible origin of the API, preferring the
+// configured public URL over the request host.
+func (h *Handler) baseURL(r *http.Request) string {
+	if h.config.Server.PublicURL != "" {
+		return h.config.Server.PublicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	return scheme + "://" + r.Host
 }
 
 // respondJSON writes a JSON response.
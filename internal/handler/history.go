@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/git"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	anonymousAuthor = "anonymous"
+	authorEmailHost = "users.noreply.quickgist"
+)
+
+// historyEntry is a revision in the history of a gist. Entries that are
+// not recorded yet carry the attachment content to store with them.
+type historyEntry struct {
+	revision   *model.Revision
+	attachment []byte
+}
+
+// gistHistory returns the recorded revisions of a gist, oldest first,
+// followed by an unrecorded revision of its current state if it changed
+// since the last one. A gist without revisions has a single revision of its
+// current state.
+func (h *Handler) gistHistory(ctx context.Context, gist *model.Gist) ([]historyEntry, error) {
+	revisions, err := h.repo.ListRevisions(ctx, gist.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]historyEntry, len(revisions), len(revisions)+1)
+	for i, rev := range revisions {
+		entries[i] = historyEntry{revision: rev}
+	}
+
+	var last *model.Revision
+	if len(revisions) > 0 {
+		last = revisions[len(revisions)-1]
+	}
+
+	current := historyEntry{revision: &model.Revision{
+		GistID:          gist.ID,
+		Number:          len(revisions) + 1,
+		UserID:          gist.UserID,
+		Message:         commitMessage(gist.Title),
+		ContentFileName: gist.ContentFileName(),
+		Content:         gist.Content,
+		CreatedAt:       gistModified(gist),
+	}}
+
+	// Attachments only change through pushes, which record revisions, so a
+	// recorded attachment of the same name is the current one.
+	if gist.FileName != "" {
+		name := path.Base(gist.FileName)
+		current.revision.AttachmentName = name
+		if last != nil && last.AttachmentName == name {
+			current.revision.AttachmentHash = last.AttachmentHash
+		} else {
+			content, err := h.readAttachment(ctx, gist.ID, gist.FileName)
+			if err != nil {
+				return nil, err
+			}
+			current.attachment = content
+			current.revision.AttachmentHash = git.HashObject(git.ObjectBlob, content).String()
+		}
+	}
+
+	if last == nil || revisionChanged(last, current.revision) {
+		entries = append(entries, current)
+	}
+	return entries, nil
+}
+
+// saveHistory records the entries that are not recorded yet, storing their
+// attachments first.
+func (h *Handler) saveHistory(ctx context.Context, gistID string, entries []historyEntry) error {
+	var revisions []*model.Revision
+	for _, entry := range entries {
+		if entry.revision.ID != "" {
+			continue
+		}
+
+		if entry.attachment != nil {
+			_, err := h.storage.Upload(ctx, gistID, revisionObject(entry.revision.AttachmentHash),
+				bytes.NewReader(entry.attachment), int64(len(entry.attachment)))
+			if err != nil {
+				return err
+			}
+		}
+		revisions = append(revisions, entry.revision)
+	}
+
+	if len(revisions) == 0 {
+		return nil
+	}
+	return h.repo.CreateRevisions(ctx, gistID, revisions)
+}
+
+// recordHistory records the current state of a gist as a revision if it
+// changed. Failures are only logged so that they never fail the write that
+// triggered them; the state is recorded by the next write instead.
+func (h *Handler) recordHistory(ctx context.Context, gist *model.Gist) {
+	entries, err := h.gistHistory(ctx, gist)
+	if err == nil {
+		err = h.saveHistory(ctx, gist.ID, entries)
+	}
+	if err != nil {
+		h.errorLog.Printf("failed to record revision of gist %s: %v", gist.ID, err)
+	}
+}
+
+// historySnapshots loads the files of every history entry.
+func (h *Handler) historySnapshots(ctx context.Context, gistID string, entries []historyEntry) ([]git.Snapshot, error) {
+	attachments := make(map[string][]byte)
+	snapshots := make([]git.Snapshot, len(entries))
+
+	for i, entry := range entries {
+		rev := entry.revision
+		snap := git.Snapshot{
+			Files:   []git.File{{Name: rev.ContentFileName, Content: []byte(rev.Content)}},
+			Message: rev.Message,
+			Author:  gitAuthor(rev.UserID, rev.CreatedAt),
+		}
+		if rev.Commit != "" {
+			snap.Commit = []byte(rev.Commit)
+		}
+
+		if rev.AttachmentName != "" {
+			content, ok := attachments[rev.AttachmentHash]
+			if entry.attachment != nil {
+				content, ok = entry.attachment, true
+			}
+			if !ok {
+				var err error
+				content, err = h.readAttachment(ctx, gistID, revisionObject(rev.AttachmentHash))
+				if err != nil {
+					return nil, err
+				}
+			}
+			attachments[rev.AttachmentHash] = content
+			snap.Files = append(snap.Files, git.File{Name: rev.AttachmentName, Content: content})
+		}
+
+		snapshots[i] = snap
+	}
+
+	return snapshots, nil
+}
+
+// deleteRevisionObjects removes the stored attachments of a gist's
+// revisions. Failures are only logged since the gist is already gone.
+func (h *Handler) deleteRevisionObjects(ctx context.Context, gistID string, revisions []*model.Revision) {
+	deleted := make(map[string]bool)
+	for _, rev := range revisions {
+		if rev.AttachmentHash == "" || deleted[rev.AttachmentHash] {
+			continue
+		}
+		deleted[rev.AttachmentHash] = true
+
+		if err := h.storage.Delete(ctx, gistID, revisionObject(rev.AttachmentHash)); err != nil {
+			h.errorLog.Printf("failed to delete revision file of gist %s: %v", gistID, err)
+		}
+	}
+}
+
+func (h *Handler) readAttachment(ctx context.Context, gistID, name string) ([]byte, error) {
+	reader, _, err := h.storage.Open(ctx, gistID, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, apperror.Storage(err)
+	}
+	return content, nil
+}
+
+func revisionChanged(last, current *model.Revision) bool {
+	return last.Content != current.Content ||
+		last.ContentFileName != current.ContentFileName ||
+		last.AttachmentName != current.AttachmentName ||
+		last.AttachmentHash != current.AttachmentHash
+}
+
+func revisionObject(hash string) string {
+	return model.RevisionObject(hash)
+}
+
+func commitMessage(title string) string {
+	if title = strings.TrimSpace(title); title != "" {
+		return title
+	}
+	return "Update gist"
+}
+
+func gitAuthor(userID string, when time.Time) git.Signature {
+	name := userID
+	if name == "" {
+		name = anonymousAuthor
+	}
+	return git.Signature{
+		Name:  name,
+		Email: name + "@" + authorEmailHost,
+		When:  when.UTC(),
+	}
+}
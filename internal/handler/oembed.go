@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	oEmbedDefaultWidth = 640
+	oEmbedMaxHeight    = 600
+	oEmbedLineHeight   = 18
+	oEmbedChromeHeight = 60
+	oEmbedCacheAge     = 3600
+)
+
+// OEmbed handles GET /oembed
+func (h *Handler) OEmbed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if format := query.Get("format"); format != "" && format != "json" {
+		h.respondError(w, apperror.NotImplemented("only the json format is supported"))
+		return
+	}
+
+	rawURL := strings.TrimSpace(query.Get("url"))
+	if rawURL == "" {
+		h.respondError(w, apperror.BadRequest("url is required"))
+		return
+	}
+
+	id, ok := h.gistIDFromURL(r, rawURL)
+	if !ok {
+		h.respondError(w, apperror.NotFound("gist"))
+		return
+	}
+
+	maxWidth, err := optionalPositiveInt(query.Get("maxwidth"))
+	if err != nil {
+		h.respondError(w, apperror.BadRequest("maxwidth must be a positive integer"))
+		return
+	}
+	maxHeight, err := optionalPositiveInt(query.Get("maxheight"))
+	if err != nil {
+		h.respondError(w, apperror.BadRequest("maxheight must be a positive integer"))
+		return
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	// The embed iframe is loaded anonymously, so private gists cannot be
+	// embedded even when their owner asks.
+	if gist.Visibility == model.VisibilityPrivate {
+		h.respondError(w, apperror.Unauthorized("gist is private"))
+		return
+	}
+
+	width, height := oEmbedSize(gist, maxWidth, maxHeight)
+	embedURL := fmt.Sprintf("%s/gist/%s/embed", h.baseURL(r), url.PathEscape(gist.ID))
+
+	h.respondJSON(w, http.StatusOK, OEmbedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        gist.Title,
+		ProviderName: "QuickGist",
+		ProviderURL:  h.config.Server.AppURL,
+		CacheAge:     oEmbedCacheAge,
+		HTML: fmt.Sprintf(
+			`<iframe src="%s" width="%d" height="%d" title="%s" frameborder="0" loading="lazy"></iframe>`,
+			template.HTMLEscapeString(embedURL), width, height, template.HTMLEscapeString(gist.Title),
+		),
+		Width:  width,
+		Height: height,
+	})
+}
+
+// gistIDFromURL extracts a gist ID from a link to the web app or the API.
+// Links to other hosts are rejected.
+func (h *Handler) gistIDFromURL(r *http.Request, rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+
+	if !h.isOwnHost(r, u.Host) {
+		return "", false
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(segments) == 2 && segments[0] == "view":
+		return segments[1], segments[1] != ""
+	case len(segments) == 3 && segments[0] == "gist" && segments[1] == "view":
+		return segments[2], segments[2] != ""
+	case len(segments) == 3 && segments[0] == "gist" && segments[2] == "embed":
+		return segments[1], segments[1] != ""
+	case len(segments) == 2 && segments[0] == "gist":
+		id := strings.TrimSuffix(segments[1], ".js")
+		return id, id != ""
+	}
+
+	return "", false
+}
+
+// isOwnHost reports whether host belongs to the web app or this API.
+func (h *Handler) isOwnHost(r *http.Request, host string) bool {
+	for _, own := range []string{h.config.Server.AppURL, h.baseURL(r)} {
+		if u, err := url.Parse(own); err == nil && strings.EqualFold(u.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// oEmbedSize picks iframe dimensions that fit the gist's content within
+// the consumer's limits.
+func oEmbedSize(g *model.Gist, maxWidth, maxHeight int) (int, int) {
+	width := oEmbedDefaultWidth
+	if maxWidth > 0 && maxWidth < width {
+		width = maxWidth
+	}
+
+	lines := strings.Count(g.Content, "\n") + 1
+	height := lines*oEmbedLineHeight + oEmbedChromeHeight
+	if height > oEmbedMaxHeight {
+		height = oEmbedMaxHeight
+	}
+	if maxHeight > 0 && maxHeight < height {
+		height = maxHeight
+	}
+
+	return width, height
+}
+
+func optionalPositiveInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid positive integer %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/render"
+)
+
+// Rendered handles GET /gist/:id/rendered
+func (h *Handler) Rendered(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	if gist.ResolvedLanguage() != "markdown" {
+		h.respondError(w, apperror.Validation("only markdown gists can be rendered"))
+		return
+	}
+
+	html, err := render.Markdown(gist.Content)
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, RenderedGistResponse{
+		SnippetID: gist.ID,
+		HTML:      html,
+	})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/language"
+	"github.com/abhisheksharm-3/quickgist/internal/search"
+)
+
+const dateLayout = "2006-01-02"
+
+// Search handles GET /gist/search
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	clauses := search.ParseQuery(params.Get("q"))
+	if len(clauses) == 0 {
+		h.respondError(w, apperror.BadRequest("q is required"))
+		return
+	}
+
+	callerID := h.callerID(r)
+	query := search.Query{
+		Clauses:  clauses,
+		CallerID: callerID,
+	}
+
+	if lang := strings.TrimSpace(params.Get("language")); lang != "" {
+		normalized, ok := language.Normalize(lang)
+		if !ok {
+			h.respondError(w, apperror.Validation("unsupported language"))
+			return
+		}
+		query.Language = normalized
+	}
+
+	var err error
+	if query.From, err = parseTimeParam(params.Get("from"), false); err != nil {
+		h.respondError(w, apperror.Validation("from must be a date or RFC 3339 timestamp"))
+		return
+	}
+	if query.To, err = parseTimeParam(params.Get("to"), true); err != nil {
+		h.respondError(w, apperror.Validation("to must be a date or RFC 3339 timestamp"))
+		return
+	}
+	if query.Limit, err = optionalPositiveInt(params.Get("limit")); err != nil {
+		h.respondError(w, apperror.BadRequest("limit must be a positive integer"))
+		return
+	}
+
+	fields, err := parseFields(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	hits, err := h.index.Search(query)
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+
+	results := make([]GistFields, 0, len(hits))
+	for _, hit := range hits {
+		gist, err := h.getGist(r.Context(), hit.ID)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			continue
+		}
+		if err != nil {
+			h.respondError(w, err)
+			return
+		}
+		if !gist.CanView(callerID) || gist.Expired(time.Now()) {
+			continue
+		}
+
+		result := selectFields(gist, fields)
+		result["score"] = hit.Score
+		results = append(results, result)
+	}
+
+	h.respondJSON(w, http.StatusOK, SearchResponse{Results: results})
+}
+
+// parseTimeParam accepts a date or an RFC 3339 timestamp. A bare date used
+// as an upper bound covers the whole day.
+func parseTimeParam(s string, endOfDay bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
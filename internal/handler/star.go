@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+// Star handles PUT /gist/:id/star
+func (h *Handler) Star(w http.ResponseWriter, r *http.Request) {
+	h.setStar(w, r, true)
+}
+
+// Unstar handles DELETE /gist/:id/star
+func (h *Handler) Unstar(w http.ResponseWriter, r *http.Request) {
+	h.setStar(w, r, false)
+}
+
+// setStar stars or unstars a gist for the caller. Both operations are
+// idempotent.
+func (h *Handler) setStar(w http.ResponseWriter, r *http.Request, starred bool) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+
+	if id == "" {
+		h.respondError(w, apperror.BadRequest("gist ID is required"))
+		return
+	}
+
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	gist, err := h.getVisibleGist(r, id)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	if starred {
+		_, err = h.repo.Star(r.Context(), callerID, gist.ID)
+	} else {
+		_, err = h.repo.Unstar(r.Context(), callerID, gist.ID)
+	}
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.cache.Delete(gist.ID)
+
+	h.respondJSON(w, http.StatusOK, StarResponse{
+		SnippetID: gist.ID,
+		Starred:   starred,
+		Stars:     h.starCount(r.Context(), gist.ID),
+	})
+}
+
+// ListStars handles GET /me/stars
+func (h *Handler) ListStars(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	params := r.URL.Query()
+	var opts repository.ListOptions
+	if opts.Limit, err = optionalPositiveInt(params.Get("limit")); err != nil {
+		h.respondError(w, apperror.BadRequest("limit must be a positive integer"))
+		return
+	}
+	opts.Cursor = strings.TrimSpace(params.Get("cursor"))
+
+	fields, err := parseFields(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	stars, next, err := h.repo.ListStars(r.Context(), callerID, opts)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	// Starred gists that were since deleted, expired or made private are
+	// skipped, so a page can hold fewer gists than requested.
+	gists := make([]GistFields, 0, len(stars))
+	for _, star := range stars {
+		gist, err := h.getGist(r.Context(), star.GistID)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			continue
+		}
+		if err != nil {
+			h.respondError(w, err)
+			return
+		}
+		if !gist.CanView(callerID) || gist.Expired(time.Now()) {
+			continue
+		}
+
+		result := selectFields(gist, fields)
+		result["starredAt"] = star.CreatedAt
+		gists = append(gists, result)
+	}
+
+	h.respondJSON(w, http.StatusOK, GistListResponse{
+		Gists:      gists,
+		NextCursor: next,
+	})
+}
+
+// cachedStarCount returns the number of stars of a gist loaded through
+// getGist. The count is cached with the gist, so that views of popular
+// gists do not sum the counter shards each time; starring and unstarring
+// drop the cached gist.
+func (h *Handler) cachedStarCount(ctx context.Context, gist *model.Gist) int64 {
+	if gist.Stars != nil {
+		return *gist.Stars
+	}
+
+	count, err := h.repo.StarCount(ctx, gist.ID)
+	if err != nil {
+		h.errorLog.Printf("failed to count stars for gist %s: %v", gist.ID, err)
+		return 0
+	}
+
+	// Cached gists are shared between requests, so a copy carrying the
+	// count replaces the entry, unless the gist changed meanwhile.
+	if cached, ok := h.cache.Get(gist.ID); ok && cached == gist {
+		withStars := *gist
+		withStars.Stars = &count
+		h.cache.Set(gist.ID, &withStars, cacheTTL)
+	}
+	return count
+}
+
+// starCount returns the number of stars of a gist. Failures are logged and
+// reported as zero so that they never fail the request.
+func (h *Handler) starCount(ctx context.Context, gistID string) int64 {
+	count, err := h.repo.StarCount(ctx, gistID)
+	if err != nil {
+		h.errorLog.Printf("failed to count stars for gist %s: %v", gistID, err)
+		return 0
+	}
+	return count
+}
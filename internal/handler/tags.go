@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+)
+
+// UserTags handles GET /me/tags
+func (h *Handler) UserTags(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	counts, err := h.repo.TagCounts(r.Context(), userID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	h.respondJSON(w, http.StatusOK, TagsResponse{Tags: tags})
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	maxTokensPerUser    = 50
+	maxTokenNameLength  = 100
+	maxTokenRequestSize = 4 << 10
+)
+
+// tokenUserKey is the context key under which Authenticate stores the ID
+// of the user an API token belongs to.
+type tokenUserKey struct{}
+
+// Authenticate resolves an API token, sent as a bearer token or as the
+// password of basic authentication as git does, to the user it belongs
+// to. Requests without a token pass through unchanged; requests with an
+// unknown token are rejected.
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := requestToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, err := h.repo.FindToken(r.Context(), model.HashToken(secret))
+		if err != nil {
+			if apperror.Is(err, apperror.CodeNotFound) {
+				err = apperror.Unauthorized("invalid API token")
+				w.Header().Set("WWW-Authenticate", authChallenge)
+			}
+			h.respondError(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), tokenUserKey{}, token.UserID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CreateToken handles POST /me/tokens
+//
+// Only a caller already authenticated with a token can create more; the
+// first token of a user is issued with quickgist-admin issue-token.
+func (h *Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	var req CreateTokenRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxTokenRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		h.respondError(w, apperror.Validation("name is required"))
+		return
+	}
+	if len(name) > maxTokenNameLength {
+		h.respondError(w, apperror.Validation("name is too long"))
+		return
+	}
+
+	existing, err := h.repo.ListTokens(r.Context(), callerID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if len(existing) >= maxTokensPerUser {
+		h.respondError(w, apperror.Validation("token limit reached"))
+		return
+	}
+
+	secret, err := model.NewTokenSecret()
+	if err != nil {
+		h.respondError(w, apperror.Internal(err))
+		return
+	}
+
+	token := model.NewAPIToken(callerID, name, secret)
+	id, err := h.repo.CreateToken(r.Context(), token)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	token.ID = id
+
+	resp := tokenToResponse(token)
+	resp.Token = secret
+
+	h.respondJSON(w, http.StatusCreated, resp)
+}
+
+// ListTokens handles GET /me/tokens
+func (h *Handler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	tokens, err := h.repo.ListTokens(r.Context(), callerID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	resp := TokenListResponse{Tokens: make([]TokenResponse, len(tokens))}
+	for i, token := range tokens {
+		resp.Tokens[i] = tokenToResponse(token)
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// DeleteToken handles DELETE /me/tokens/:id
+func (h *Handler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	token, err := h.repo.GetToken(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if token.UserID != callerID {
+		h.respondError(w, apperror.NotFound("token"))
+		return
+	}
+
+	if err := h.repo.DeleteToken(r.Context(), token.ID); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tokenUser returns the ID of the user whose API token authenticated the
+// request.
+func tokenUser(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(tokenUserKey{}).(string)
+	return userID, ok
+}
+
+// requestToken extracts an API token from the Authorization header.
+func requestToken(r *http.Request) (string, bool) {
+	if _, password, ok := r.BasicAuth(); ok {
+		return password, password != ""
+	}
+
+	// GitHub clients send "token" rather than "Bearer".
+	scheme, secret, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || (!strings.EqualFold(scheme, "Bearer") && !strings.EqualFold(scheme, "token")) {
+		return "", false
+	}
+	secret = strings.TrimSpace(secret)
+	return secret, secret != ""
+}
+
+func tokenToResponse(token *model.APIToken) TokenResponse {
+	return TokenResponse{
+		ID:        token.ID,
+		Name:      token.Name,
+		CreatedAt: token.CreatedAt,
+	}
+}
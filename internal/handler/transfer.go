@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// transferTimeout bounds requests that move attachments, archives,
+// exports or packs. The server's read and write timeouts are sized for
+// small JSON requests and would cut such transfers off on slow
+// connections. It matches the time storage allows for an upload.
+const transferTimeout = 5 * time.Minute
+
+// allowTransfer gives the current request transferTimeout to read its body
+// and write its response, in place of the server's timeouts. It is called
+// once the request is known to be allowed, before the transfer starts. If
+// the deadlines cannot be changed, the server's timeouts stay in effect.
+func (h *Handler) allowTransfer(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(transferTimeout)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		h.errorLog.Printf("failed to extend read deadline: %v", err)
+		return
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		h.errorLog.Printf("failed to extend write deadline: %v", err)
+	}
+}
@@ -1,18 +1,87 @@
 package handler
 
-import "time"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
 
 // GistResponse represents a gist in API responses.
 type GistResponse struct {
-	SnippetID   string    `json:"snippetId"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Content     string    `json:"content"`
-	IsDraft     bool      `json:"isDraft"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UserID      string    `json:"userId,omitempty"`
-	FileName    string    `json:"fileName,omitempty"`
-	FileURL     string    `json:"fileURL,omitempty"`
+	SnippetID     string     `json:"snippetId"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Content       string     `json:"content"`
+	Language      string     `json:"language"`
+	Tags          []string   `json:"tags,omitempty"`
+	IsDraft       bool       `json:"isDraft"`
+	Visibility    string     `json:"visibility"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	Stars         int64      `json:"stars"`
+	Starred       bool       `json:"starred,omitempty"`
+	ViewCount     int64      `json:"viewCount"`
+	DownloadCount int64      `json:"downloadCount"`
+	UserID        string     `json:"userId,omitempty"`
+	FileName      string     `json:"fileName,omitempty"`
+	FileURL       string     `json:"fileURL,omitempty"`
+	FileSHA256    string     `json:"fileSha256,omitempty"`
+}
+
+// GistFields represents a gist restricted to the fields requested by a
+// listing endpoint, keyed by their GistResponse JSON names.
+type GistFields map[string]interface{}
+
+// GistListResponse represents a page of gists.
+type GistListResponse struct {
+	Gists      []GistFields `json:"gists"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+}
+
+// SearchResponse represents the results of a gist search. Each result also
+// carries its relevance score.
+type SearchResponse struct {
+	Results []GistFields `json:"results"`
+}
+
+// RenderedGistResponse represents a gist rendered to sanitized HTML.
+type RenderedGistResponse struct {
+	SnippetID string `json:"snippetId"`
+	HTML      string `json:"html"`
+}
+
+// OEmbedResponse represents a rich oEmbed response.
+type OEmbedResponse struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title,omitempty"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	CacheAge     int    `json:"cache_age"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// ArchiveManifest describes the contents of a downloaded gist archive.
+type ArchiveManifest struct {
+	SnippetID   string                `json:"snippetId"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Language    string                `json:"language"`
+	Visibility  string                `json:"visibility"`
+	IsDraft     bool                  `json:"isDraft"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	UserID      string                `json:"userId,omitempty"`
+	Files       []ArchiveManifestFile `json:"files"`
+}
+
+// ArchiveManifestFile describes a single file in a gist archive.
+type ArchiveManifestFile struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Size int64  `json:"size"`
 }
 
 // CreateGistRequest represents the request to create a gist.
@@ -20,10 +89,322 @@ type CreateGistRequest struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Content     string `json:"content"`
+	Language    string `json:"language,omitempty"`
 	IsDraft     bool   `json:"isDraft"`
+	Visibility  string `json:"visibility,omitempty"`
+	Tags        string `json:"tags,omitempty"`
 	UserID      string `json:"userId"`
 }
 
+// UpdateGistRequest represents the request to edit a gist. Only the fields
+// that are present are changed.
+type UpdateGistRequest struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	Content     *string   `json:"content"`
+	Language    *string   `json:"language"`
+	IsDraft     *bool     `json:"isDraft"`
+	Visibility  *string   `json:"visibility"`
+	Tags        *[]string `json:"tags"`
+}
+
+// StarResponse represents the star state of a gist for the caller.
+type StarResponse struct {
+	SnippetID string `json:"snippetId"`
+	Starred   bool   `json:"starred"`
+	Stars     int64  `json:"stars"`
+}
+
+// CreateCommentRequest represents the request to comment on a gist.
+type CreateCommentRequest struct {
+	Body     string         `json:"body"`
+	ParentID string         `json:"parentId,omitempty"`
+	Anchor   *CommentAnchor `json:"anchor,omitempty"`
+}
+
+// UpdateCommentRequest represents the request to edit a comment.
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// CommentAnchor represents the lines of a gist file a comment refers to.
+type CommentAnchor struct {
+	FileName  string `json:"fileName"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Revision  string `json:"revision,omitempty"`
+}
+
+// CommentResponse represents a comment and its replies in API responses.
+type CommentResponse struct {
+	ID        string            `json:"id"`
+	ParentID  string            `json:"parentId,omitempty"`
+	UserID    string            `json:"userId"`
+	Body      string            `json:"body"`
+	BodyHTML  string            `json:"bodyHtml"`
+	Anchor    *CommentAnchor    `json:"anchor,omitempty"`
+	Deleted   bool              `json:"deleted,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt *time.Time        `json:"updatedAt,omitempty"`
+	Replies   []CommentResponse `json:"replies,omitempty"`
+}
+
+// CommentsResponse represents the comment threads of a gist.
+type CommentsResponse struct {
+	Comments []CommentResponse `json:"comments"`
+}
+
+// CreateWebhookRequest represents the request to register a webhook.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// WebhookResponse represents a webhook in API responses. The secret is only
+// returned when the webhook is created.
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookListResponse lists the webhooks of a user.
+type WebhookListResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+// DeliveryResponse represents a webhook delivery in API responses.
+type DeliveryResponse struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhookId"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	RedeliveryOf   string          `json:"redeliveryOf,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	LastAttemptAt  *time.Time      `json:"lastAttemptAt,omitempty"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+}
+
+// DeliveryListResponse represents a page of webhook deliveries.
+type DeliveryListResponse struct {
+	Deliveries []DeliveryResponse `json:"deliveries"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+// ExportResponse represents a personal data export in API responses.
+type ExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Gists       int        `json:"gists"`
+	Revisions   int        `json:"revisions"`
+	Attachments int        `json:"attachments"`
+	Size        int64      `json:"size,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+}
+
+// ExportListResponse lists the exports of a user.
+type ExportListResponse struct {
+	Exports []ExportResponse `json:"exports"`
+}
+
+// ExportRecord is a line of the data file of an export. Type names the
+// field that is set: gist, revision or comment.
+type ExportRecord struct {
+	Type     string          `json:"type"`
+	Gist     *GistResponse   `json:"gist,omitempty"`
+	Revision *ExportRevision `json:"revision,omitempty"`
+	Comment  *ExportComment  `json:"comment,omitempty"`
+}
+
+// ExportRevision represents a gist revision in an export.
+type ExportRevision struct {
+	GistID          string    `json:"gistId"`
+	Number          int       `json:"number"`
+	Message         string    `json:"message"`
+	ContentFileName string    `json:"contentFileName"`
+	Content         string    `json:"content"`
+	AttachmentName  string    `json:"attachmentName,omitempty"`
+	AttachmentHash  string    `json:"attachmentHash,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// ExportComment represents a comment in an export.
+type ExportComment struct {
+	GistID string `json:"gistId"`
+	CommentResponse
+}
+
+// DeleteAccountRequest represents the request to delete an account.
+type DeleteAccountRequest struct {
+	Confirm string `json:"confirm"`
+}
+
+// DeletionReport counts what deleting an account removed, or would
+// remove when DryRun is set.
+type DeletionReport struct {
+	DryRun      bool `json:"dryRun"`
+	Gists       int  `json:"gists"`
+	Attachments int  `json:"attachments"`
+	Comments    int  `json:"comments"`
+	Stars       int  `json:"stars"`
+	Webhooks    int  `json:"webhooks"`
+	Deliveries  int  `json:"deliveries"`
+	Exports     int  `json:"exports"`
+	Tokens      int  `json:"tokens"`
+}
+
+// GitHubGist represents a gist in the shape of the GitHub Gists API.
+type GitHubGist struct {
+	URL         string                `json:"url"`
+	ID          string                `json:"id"`
+	HTMLURL     string                `json:"html_url"`
+	GitPullURL  string                `json:"git_pull_url"`
+	GitPushURL  string                `json:"git_push_url"`
+	Files       map[string]GitHubFile `json:"files"`
+	Public      bool                  `json:"public"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+	Description string                `json:"description"`
+	Owner       *GitHubUser           `json:"owner"`
+	Truncated   bool                  `json:"truncated"`
+}
+
+// GitHubFile represents a file of a gist in the GitHub Gists API.
+type GitHubFile struct {
+	Filename string `json:"filename"`
+	Type     string `json:"type"`
+	Language string `json:"language,omitempty"`
+	RawURL   string `json:"raw_url"`
+	Size     int64  `json:"size"`
+	Content  string `json:"content,omitempty"`
+}
+
+// GitHubUser represents a gist owner in the GitHub Gists API.
+type GitHubUser struct {
+	Login string `json:"login"`
+}
+
+// GitHubFileInput represents a file in GitHub Gists API requests. A null
+// file in an update removes it.
+type GitHubFileInput struct {
+	Content  *string `json:"content"`
+	Filename *string `json:"filename"`
+}
+
+// GitHubCreateRequest represents the GitHub request to create a gist.
+type GitHubCreateRequest struct {
+	Description string                      `json:"description"`
+	Public      bool                        `json:"public"`
+	Files       map[string]*GitHubFileInput `json:"files"`
+}
+
+// GitHubUpdateRequest represents the GitHub request to update a gist.
+type GitHubUpdateRequest struct {
+	Description *string                     `json:"description"`
+	Files       map[string]*GitHubFileInput `json:"files"`
+}
+
+// WebhookPayload is the body delivered to webhooks.
+type WebhookPayload struct {
+	Event     string     `json:"event"`
+	Timestamp time.Time  `json:"timestamp"`
+	Gist      GistFields `json:"gist"`
+}
+
+// AtomFeed is an Atom syndication feed.
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  AtomPerson  `xml:"author"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// AtomEntry is a single entry of an Atom feed.
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Link       AtomLink       `xml:"link"`
+	Summary    *AtomText      `xml:"summary,omitempty"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+// AtomLink is an Atom link element.
+type AtomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+// AtomPerson is an Atom author.
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomText is an Atom text construct.
+type AtomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// AtomCategory is an Atom category.
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// JSONFeed is a JSON Feed 1.1 document.
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Authors     []JSONFeedAuthor `json:"authors"`
+	Items       []JSONFeedItem   `json:"items"`
+}
+
+// JSONFeedAuthor is a JSON Feed author.
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSONFeedItem is a single item of a JSON Feed.
+type JSONFeedItem struct {
+	ID            string     `json:"id"`
+	URL           string     `json:"url"`
+	Title         string     `json:"title"`
+	ContentText   string     `json:"content_text"`
+	Summary       string     `json:"summary,omitempty"`
+	DatePublished time.Time  `json:"date_published"`
+	DateModified  *time.Time `json:"date_modified,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+}
+
+// TagsResponse lists the tags used by a user.
+type TagsResponse struct {
+	Tags []TagCount `json:"tags"`
+}
+
+// TagCount represents a tag and the number of gists carrying it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
 // ErrorResponse represents an error in API responses.
 type ErrorResponse struct {
 	Code    string `json:"code"`
@@ -42,3 +423,22 @@ type HomeResponse struct {
 	Message string `json:"message"`
 	Version string `json:"version"`
 }
+
+// CreateTokenRequest represents the request to create an API token.
+type CreateTokenRequest struct {
+	Name string `json:"name"`
+}
+
+// TokenResponse represents an API token in API responses. The secret is
+// only returned when the token is created.
+type TokenResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Token     string    `json:"token,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TokenListResponse lists the API tokens of a user.
+type TokenListResponse struct {
+	Tokens []TokenResponse `json:"tokens"`
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+)
+
+const (
+	// maxUploadSize limits attachments streamed by Create. Other paths
+	// hold attachments in memory and keep to maxFileSize.
+	maxUploadSize = 50 << 20
+	// maxFormValuesSize limits the form fields of Create other than the
+	// attachment, taken together.
+	maxFormValuesSize = maxUpdateSize
+	// maxCreateSize limits a whole create request, leaving room for the
+	// part headers and boundaries.
+	maxCreateSize = maxUploadSize + maxFormValuesSize + 64<<10
+)
+
+// stagedAttachment is an attachment uploaded in full to the staging area
+// and waiting to be moved under its gist.
+type stagedAttachment struct {
+	name     string
+	path     string
+	size     int64
+	checksum string
+}
+
+// createForm is a parsed create request. Its attachment, if any, is
+// already staged.
+type createForm struct {
+	values     url.Values
+	attachment *stagedAttachment
+}
+
+// uploadReader counts and hashes the content read through it and fails
+// once more than limit bytes are read. The first error is kept so that it
+// is reported even by storage that ignores it.
+type uploadReader struct {
+	r     io.Reader
+	hash  hash.Hash
+	n     int64
+	limit int64
+	err   error
+}
+
+var errUploadTooLarge = errors.New("upload exceeds maximum size")
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+
+	n, err := u.r.Read(p)
+	u.n += int64(n)
+	if u.n > u.limit {
+		n -= int(u.n - u.limit)
+		u.n = u.limit
+		err = errUploadTooLarge
+	}
+	u.hash.Write(p[:n])
+	if err != nil && err != io.EOF {
+		u.err = err
+	}
+	return n, err
+}
+
+// readCreateForm reads a multipart create request part by part. The file
+// part is streamed to the staging area as it arrives, so neither the
+// attachment nor the form is buffered beyond its field values. If reading
+// fails, nothing is left staged.
+func (h *Handler) readCreateForm(w http.ResponseWriter, r *http.Request) (*createForm, error) {
+	h.allowTransfer(w)
+	r.Body = http.MaxBytesReader(w, r.Body, maxCreateSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, apperror.BadRequest("request must be a multipart form")
+	}
+
+	form := &createForm{values: url.Values{}}
+	remaining := int64(maxFormValuesSize)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			h.discardAttachment(r.Context(), form.attachment)
+			return nil, formError(err)
+		}
+
+		if err := h.readPart(r.Context(), form, part, &remaining); err != nil {
+			part.Close()
+			h.discardAttachment(r.Context(), form.attachment)
+			return nil, err
+		}
+		part.Close()
+	}
+}
+
+// readPart adds a single part to form, staging it if it is the
+// attachment. remaining is the size left for field values.
+func (h *Handler) readPart(ctx context.Context, form *createForm, part *multipart.Part, remaining *int64) error {
+	name := part.FormName()
+	if name == "" {
+		return nil
+	}
+
+	// A file input left empty is sent as a file part without a name.
+	if name == "file" && part.FileName() != "" {
+		if form.attachment != nil {
+			return apperror.Validation("only one file may be attached")
+		}
+		staged, err := h.stageAttachment(ctx, part.FileName(), part, maxUploadSize)
+		if err != nil {
+			return err
+		}
+		form.attachment = staged
+		return nil
+	}
+
+	value, err := io.ReadAll(io.LimitReader(part, *remaining+1))
+	if err != nil {
+		return formError(err)
+	}
+	if int64(len(value)) > *remaining {
+		return apperror.Validation("form fields exceed maximum size")
+	}
+	*remaining -= int64(len(value))
+	form.values.Add(name, string(value))
+	return nil
+}
+
+// formError describes a failure to read a create request.
+func formError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperror.BadRequest("request too large")
+	}
+	return apperror.BadRequest("invalid form")
+}
+
+// stageAttachment uploads an attachment of at most limit bytes to the
+// staging area while computing its checksum. If the upload fails, nothing
+// is left staged.
+func (h *Handler) stageAttachment(ctx context.Context, name string, content io.Reader, limit int64) (*stagedAttachment, error) {
+	path, err := stagingName(name)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	reader := &uploadReader{r: content, hash: sha256.New(), limit: limit}
+	_, err = h.storage.Upload(ctx, stagingStorageID, path, reader, -1)
+	if reader.err != nil || err != nil {
+		h.removeFile(ctx, stagingStorageID, path)
+	}
+	switch {
+	case errors.Is(reader.err, errUploadTooLarge):
+		return nil, apperror.Validation("file exceeds maximum size")
+	case reader.err != nil:
+		return nil, formError(reader.err)
+	case err != nil:
+		return nil, attachmentError(err)
+	}
+
+	return &stagedAttachment{
+		name:     name,
+		path:     path,
+		size:     reader.n,
+		checksum: hex.EncodeToString(reader.hash.Sum(nil)),
+	}, nil
+}
+
+// discardAttachment removes a staged attachment of a gist that is not
+// created after all.
+func (h *Handler) discardAttachment(ctx context.Context, staged *stagedAttachment) {
+	if staged != nil {
+		h.removeFile(ctx, stagingStorageID, staged.path)
+	}
+}
+
+// removeFile deletes a file that a failed write left behind, even when the
+// request was cancelled. Failures are only logged.
+func (h *Handler) removeFile(ctx context.Context, gistID, name string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	if err := h.storage.Delete(ctx, gistID, name); err != nil {
+		h.errorLog.Printf("failed to remove file %s/%s: %v", gistID, name, err)
+	}
+}
+
+// stagingName returns a unique name for staging an attachment.
+func stagingName(name string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b) + "/" + name, nil
+}
+
+// attachmentError reports that a gist was not created because its
+// attachment could not be stored.
+func attachmentError(err error) error {
+	return apperror.Wrap(err, "the attachment could not be saved, so the gist was not created")
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+const (
+	maxWebhooksPerUser  = 20
+	maxWebhookURLLength = 2048
+	maxSecretLength     = 256
+	maxWebhookBodySize  = 16 << 10
+)
+
+// CreateWebhook handles POST /me/webhooks
+//
+// Webhooks are only notified of changes the owner makes with an API token.
+// The web app creates gists without one, naming the user in the form, and
+// such gists are not delivered since anyone could have named them.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	var req CreateWebhookRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, apperror.BadRequest("request too large or invalid JSON"))
+		return
+	}
+
+	targetURL, err := h.validateWebhookURL(req.URL)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	events := model.WebhookEvents
+	if len(req.Events) > 0 {
+		events = nil
+		for _, name := range req.Events {
+			event, ok := model.ParseWebhookEvent(strings.TrimSpace(name))
+			if !ok {
+				h.respondError(w, apperror.Validation("unknown event "+name))
+				return
+			}
+			events = append(events, event)
+		}
+	}
+
+	secret := req.Secret
+	if len(secret) > maxSecretLength {
+		h.respondError(w, apperror.Validation("secret is too long"))
+		return
+	}
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			h.respondError(w, apperror.Internal(err))
+			return
+		}
+	}
+
+	existing, err := h.repo.ListWebhooks(r.Context(), callerID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		h.respondError(w, apperror.Validation("webhook limit reached"))
+		return
+	}
+
+	hook := model.NewWebhook(callerID, targetURL, secret, events)
+	id, err := h.repo.CreateWebhook(r.Context(), hook)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	hook.ID = id
+
+	resp := webhookToResponse(hook)
+	resp.Secret = hook.Secret
+
+	h.respondJSON(w, http.StatusCreated, resp)
+}
+
+// ListWebhooks handles GET /me/webhooks
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	hooks, err := h.repo.ListWebhooks(r.Context(), callerID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	resp := WebhookListResponse{Webhooks: make([]WebhookResponse, len(hooks))}
+	for i, hook := range hooks {
+		resp.Webhooks[i] = webhookToResponse(hook)
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// DeleteWebhook handles DELETE /me/webhooks/:id
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, err := h.getOwnWebhook(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	if err := h.repo.DeleteWebhook(r.Context(), hook.ID); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /me/webhooks/:id/deliveries
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	hook, err := h.getOwnWebhook(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	params := r.URL.Query()
+	var opts repository.ListOptions
+	if opts.Limit, err = optionalPositiveInt(params.Get("limit")); err != nil {
+		h.respondError(w, apperror.BadRequest("limit must be a positive integer"))
+		return
+	}
+	opts.Cursor = strings.TrimSpace(params.Get("cursor"))
+
+	deliveries, next, err := h.repo.ListDeliveries(r.Context(), hook.ID, opts)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	resp := DeliveryListResponse{
+		Deliveries: make([]DeliveryResponse, len(deliveries)),
+		NextCursor: next,
+	}
+	for i, d := range deliveries {
+		resp.Deliveries[i] = deliveryToResponse(d)
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// Redeliver handles POST /me/webhooks/:id/deliveries/:deliveryId/redeliver
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	hook, err := h.getOwnWebhook(r)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	deliveryID := strings.TrimSpace(chi.URLParam(r, "deliveryId"))
+	if deliveryID == "" {
+		h.respondError(w, apperror.BadRequest("delivery ID is required"))
+		return
+	}
+
+	previous, err := h.repo.GetDelivery(r.Context(), deliveryID)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	if previous.WebhookID != hook.ID {
+		h.respondError(w, apperror.NotFound("delivery"))
+		return
+	}
+
+	delivery, err := h.webhooks.Redeliver(r.Context(), previous)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, deliveryToResponse(delivery))
+}
+
+// getOwnWebhook loads the webhook addressed by the request, reporting it as
+// not found unless the caller owns it.
+func (h *Handler) getOwnWebhook(r *http.Request) (*model.Webhook, error) {
+	callerID, err := h.requireCaller(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		return nil, apperror.BadRequest("webhook ID is required")
+	}
+
+	hook, err := h.repo.GetWebhook(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if hook.UserID != callerID {
+		return nil, apperror.NotFound("webhook")
+	}
+
+	return hook, nil
+}
+
+// validateWebhookURL requires an absolute http(s) URL, and https outside
+// development.
+func (h *Handler) validateWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", apperror.Validation("url is required")
+	}
+	if len(raw) > maxWebhookURLLength {
+		return "", apperror.Validation("url is too long")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", apperror.Validation("url must be an absolute http or https URL")
+	}
+	if u.Scheme != "https" && !h.config.Server.IsDevelopment() {
+		return "", apperror.Validation("url must use https")
+	}
+	if u.User != nil {
+		return "", apperror.Validation("url must not contain credentials")
+	}
+
+	return u.String(), nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func webhookToResponse(hook *model.Webhook) WebhookResponse {
+	events := make([]string, len(hook.Events))
+	for i, e := range hook.Events {
+		events[i] = string(e)
+	}
+
+	return WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    events,
+		CreatedAt: hook.CreatedAt,
+	}
+}
+
+func deliveryToResponse(d *model.WebhookDelivery) DeliveryResponse {
+	resp := DeliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Event:          string(d.Event),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		RedeliveryOf:   d.RedeliveryOf,
+		Payload:        json.RawMessage(d.Payload),
+		CreatedAt:      d.CreatedAt,
+	}
+
+	if !d.LastAttemptAt.IsZero() {
+		resp.LastAttemptAt = &d.LastAttemptAt
+	}
+	if d.Status == model.DeliveryPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+
+	return resp
+}
@@ -0,0 +1,146 @@
+package language
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Plaintext is the language reported when nothing more specific is found.
+const Plaintext = "text"
+
+// modelineScanLines is how many lines at each end of the content are
+// inspected for editor modelines.
+const modelineScanLines = 5
+
+var (
+	vimModeline   = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype|syntax)=([\w+#-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?mode:\s*)?([\w+#-]+)\s*(?:;.*)?-\*-`)
+	shebangLine   = regexp.MustCompile(`^#!\s*(\S+)(?:\s+(\S+))?`)
+	versionSuffix = regexp.MustCompile(`[\d.]+$`)
+)
+
+// Detect guesses the language of a gist from its attachment file name and
+// content. Editor modelines take precedence, followed by the file name,
+// a shebang line and finally content heuristics.
+func Detect(fileName, content string) string {
+	if lang := fromModeline(content); lang != "" {
+		return lang
+	}
+	if lang := fromFileName(fileName); lang != "" {
+		return lang
+	}
+	if lang := fromShebang(content); lang != "" {
+		return lang
+	}
+	if lang := fromContent(content); lang != "" {
+		return lang
+	}
+	return Plaintext
+}
+
+// Normalize resolves a user-supplied language name or alias to its
+// canonical identifier. It reports false for unknown languages.
+func Normalize(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", false
+	}
+	if _, ok := languages[name]; ok {
+		return name, true
+	}
+	if lang, ok := aliases[name]; ok {
+		return lang, true
+	}
+	return "", false
+}
+
+// Extension returns the conventional file extension, including the
+// leading dot, for a canonical language identifier.
+func Extension(lang string) string {
+	if l, ok := languages[lang]; ok && len(l.extensions) > 0 {
+		return l.extensions[0]
+	}
+	return ".txt"
+}
+
+func fromFileName(fileName string) string {
+	if fileName == "" {
+		return ""
+	}
+
+	base := strings.ToLower(path.Base(fileName))
+	if lang, ok := fileNames[base]; ok {
+		return lang
+	}
+
+	ext := path.Ext(base)
+	if ext == "" {
+		return ""
+	}
+	return extensions[ext]
+}
+
+func fromShebang(content string) string {
+	line, _, _ := strings.Cut(content, "\n")
+	m := shebangLine.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return ""
+	}
+
+	interpreter := path.Base(m[1])
+	if interpreter == "env" && m[2] != "" {
+		interpreter = path.Base(m[2])
+	}
+	interpreter = versionSuffix.ReplaceAllString(interpreter, "")
+
+	return interpreters[interpreter]
+}
+
+func fromModeline(content string) string {
+	lines := strings.Split(content, "\n")
+
+	candidates := lines
+	if len(lines) > 2*modelineScanLines {
+		candidates = append(lines[:modelineScanLines:modelineScanLines], lines[len(lines)-modelineScanLines:]...)
+	}
+
+	for _, line := range candidates {
+		for _, re := range []*regexp.Regexp{vimModeline, emacsModeline} {
+			if m := re.FindStringSubmatch(line); m != nil {
+				if lang, ok := Normalize(m[1]); ok {
+					return lang
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func fromContent(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return ""
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	best, bestScore := "", 0
+	for _, h := range heuristics {
+		score := 0
+		for _, re := range h.patterns {
+			if re.MatchString(content) {
+				score++
+			}
+		}
+		if score >= h.minScore && score > bestScore {
+			best, bestScore = h.language, score
+		}
+	}
+
+	return best
+}
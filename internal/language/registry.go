@@ -0,0 +1,241 @@
+package language
+
+import "regexp"
+
+var languages = map[string]language{
+	"bash":       {extensions: []string{".sh", ".bash", ".zsh"}},
+	"c":          {extensions: []string{".c", ".h"}},
+	"clojure":    {extensions: []string{".clj", ".cljs", ".edn"}},
+	"cpp":        {extensions: []string{".cpp", ".cc", ".cxx", ".hpp", ".hh"}},
+	"csharp":     {extensions: []string{".cs"}},
+	"css":        {extensions: []string{".css"}},
+	"dart":       {extensions: []string{".dart"}},
+	"diff":       {extensions: []string{".diff", ".patch"}},
+	"docker":     {extensions: []string{".dockerfile"}},
+	"elixir":     {extensions: []string{".ex", ".exs"}},
+	"erlang":     {extensions: []string{".erl", ".hrl"}},
+	"go":         {extensions: []string{".go"}},
+	"graphql":    {extensions: []string{".graphql", ".gql"}},
+	"haskell":    {extensions: []string{".hs"}},
+	"html":       {extensions: []string{".html", ".htm"}},
+	"ini":        {extensions: []string{".ini", ".cfg", ".conf"}},
+	"java":       {extensions: []string{".java"}},
+	"javascript": {extensions: []string{".js", ".mjs", ".cjs", ".jsx"}},
+	"json":       {extensions: []string{".json"}},
+	"kotlin":     {extensions: []string{".kt", ".kts"}},
+	"lua":        {extensions: []string{".lua"}},
+	"makefile":   {extensions: []string{".mk"}},
+	"markdown":   {extensions: []string{".md", ".markdown"}},
+	"nginx":      {extensions: []string{".nginx"}},
+	"perl":       {extensions: []string{".pl", ".pm"}},
+	"php":        {extensions: []string{".php"}},
+	"powershell": {extensions: []string{".ps1", ".psm1"}},
+	"python":     {extensions: []string{".py", ".pyw"}},
+	"r":          {extensions: []string{".r"}},
+	"ruby":       {extensions: []string{".rb"}},
+	"rust":       {extensions: []string{".rs"}},
+	"scala":      {extensions: []string{".scala"}},
+	"scss":       {extensions: []string{".scss", ".sass"}},
+	"sql":        {extensions: []string{".sql"}},
+	"swift":      {extensions: []string{".swift"}},
+	"text":       {extensions: []string{".txt", ".log"}},
+	"toml":       {extensions: []string{".toml"}},
+	"typescript": {extensions: []string{".ts", ".tsx", ".mts"}},
+	"xml":        {extensions: []string{".xml", ".svg", ".xsd"}},
+	"yaml":       {extensions: []string{".yaml", ".yml"}},
+}
+
+var extensions = func() map[string]string {
+	m := make(map[string]string)
+	for name, l := range languages {
+		for _, ext := range l.extensions {
+			m[ext] = name
+		}
+	}
+	return m
+}()
+
+var aliases = map[string]string{
+	"c#":         "csharp",
+	"c++":        "cpp",
+	"cs":         "csharp",
+	"dockerfile": "docker",
+	"golang":     "go",
+	"htm":        "html",
+	"js":         "javascript",
+	"make":       "makefile",
+	"md":         "markdown",
+	"patch":      "diff",
+	"plaintext":  "text",
+	"ps1":        "powershell",
+	"py":         "python",
+	"rb":         "ruby",
+	"rs":         "rust",
+	"sh":         "bash",
+	"shell":      "bash",
+	"ts":         "typescript",
+	"txt":        "text",
+	"yml":        "yaml",
+	"zsh":        "bash",
+}
+
+var fileNames = map[string]string{
+	".bash_profile":  "bash",
+	".bashrc":        "bash",
+	".zshrc":         "bash",
+	"cmakelists.txt": "makefile",
+	"dockerfile":     "docker",
+	"gemfile":        "ruby",
+	"gnumakefile":    "makefile",
+	"makefile":       "makefile",
+	"nginx.conf":     "nginx",
+	"rakefile":       "ruby",
+}
+
+var interpreters = map[string]string{
+	"ash":     "bash",
+	"bash":    "bash",
+	"dash":    "bash",
+	"deno":    "typescript",
+	"ksh":     "bash",
+	"lua":     "lua",
+	"node":    "javascript",
+	"perl":    "perl",
+	"php":     "php",
+	"pwsh":    "powershell",
+	"python":  "python",
+	"Rscript": "r",
+	"ruby":    "ruby",
+	"sh":      "bash",
+	"ts-node": "typescript",
+	"zsh":     "bash",
+}
+
+var heuristics = []heuristic{
+	{language: "php", minScore: 1, patterns: compile(
+		`^<\?php`,
+	)},
+	{language: "html", minScore: 1, patterns: compile(
+		`(?i)^\s*<!doctype html`,
+		`(?i)^\s*<html[\s>]`,
+	)},
+	{language: "xml", minScore: 1, patterns: compile(
+		`^\s*<\?xml\s`,
+	)},
+	{language: "diff", minScore: 2, patterns: compile(
+		`(?m)^diff --git `,
+		`(?m)^--- \S`,
+		`(?m)^\+\+\+ \S`,
+		`(?m)^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`,
+	)},
+	{language: "go", minScore: 2, patterns: compile(
+		`(?m)^package \w+\s*$`,
+		`(?m)^func (\(\w+ \*?\w+\) )?\w+\(`,
+		`(?m)^import \($`,
+		`:= `,
+		`\bfmt\.\w+\(`,
+	)},
+	{language: "rust", minScore: 2, patterns: compile(
+		`(?m)^\s*(pub )?fn \w+`,
+		`\blet mut \w+`,
+		`(?m)^use (std|crate)::`,
+		`\bimpl\b.*\{`,
+		`\w+!\(`,
+	)},
+	{language: "python", minScore: 2, patterns: compile(
+		`(?m)^\s*def \w+\(.*\):\s*$`,
+		`(?m)^\s*class \w+(\(.*\))?:\s*$`,
+		`(?m)^(from \w[\w.]* )?import \w`,
+		`(?m)^if __name__ == ['"]__main__['"]:`,
+		`\bself\.\w+`,
+		`(?m)^\s*print\(`,
+	)},
+	{language: "java", minScore: 2, patterns: compile(
+		`(?m)^import java\.`,
+		`(?m)^\s*public (final )?(class|interface|enum) \w+`,
+		`\bSystem\.out\.print`,
+		`public static void main\(String`,
+	)},
+	{language: "csharp", minScore: 2, patterns: compile(
+		`(?m)^using System`,
+		`(?m)^\s*namespace [\w.]+`,
+		`\bConsole\.Write`,
+		`(?m)^\s*(public|private|internal) (static )?(async )?\w+ \w+\(.*\)\s*$`,
+	)},
+	{language: "cpp", minScore: 2, patterns: compile(
+		`(?m)^#include\s*[<"]`,
+		`\bstd::`,
+		`(?m)^\s*(class|namespace|template)\b`,
+		`\bcout\s*<<`,
+	)},
+	{language: "c", minScore: 2, patterns: compile(
+		`(?m)^#include\s*[<"]`,
+		`\bprintf\(`,
+		`(?m)^(int|void|char|static) \w+\(.*\)\s*\{?\s*$`,
+		`\bmalloc\(`,
+	)},
+	{language: "typescript", minScore: 2, patterns: compile(
+		`(?m)^\s*(export )?interface \w+`,
+		`(?m)^\s*(export )?type \w+ =`,
+		`:\s*(string|number|boolean|void|any)\b`,
+		`(?m)^import .* from ['"]`,
+	)},
+	{language: "javascript", minScore: 2, patterns: compile(
+		`(?m)^\s*(const|let|var) \w+ =`,
+		`\bfunction\s*\w*\(`,
+		`=>`,
+		`\bconsole\.log\(`,
+		`\brequire\(['"]`,
+		`(?m)^\s*module\.exports`,
+	)},
+	{language: "ruby", minScore: 2, patterns: compile(
+		`(?m)^\s*def \w+[?!]?(\(.*\))?\s*$`,
+		`(?m)^\s*end\s*$`,
+		`(?m)^require ['"]`,
+		`\bputs\b`,
+	)},
+	{language: "sql", minScore: 1, patterns: compile(
+		`(?im)^\s*(select\s.+\sfrom|insert\s+into|update\s+\w+\s+set|delete\s+from|create\s+(table|index|view))\b`,
+	)},
+	{language: "docker", minScore: 2, patterns: compile(
+		`(?m)^FROM \S+`,
+		`(?m)^(RUN|CMD|COPY|ENTRYPOINT|WORKDIR|ENV) `,
+	)},
+	{language: "markdown", minScore: 2, patterns: compile(
+		`(?m)^#{1,6} \S`,
+		`(?m)^\s*([-*]|\d+\.) \S`,
+		`\[[^\]]+\]\([^)]+\)`,
+		"(?m)^```",
+		`\*\*[^*]+\*\*`,
+	)},
+	{language: "nginx", minScore: 2, patterns: compile(
+		`(?m)^\s*server\s*\{`,
+		`(?m)^\s*location\s+\S+\s*\{`,
+		`(?m)^\s*(listen|server_name|proxy_pass|root)\s+\S+;`,
+	)},
+	{language: "bash", minScore: 2, patterns: compile(
+		`(?m)^\s*(echo|export|source|cd|sudo) `,
+		`(?m)^\s*if \[\[? `,
+		`(?m)^\s*(fi|done|esac)\s*$`,
+		`\$\{?\w+\}?`,
+	)},
+	{language: "css", minScore: 2, patterns: compile(
+		`(?m)^\s*[.#]?[\w-]+(\s*[,>+~]?\s*[.#]?[\w-]+)*\s*\{\s*$`,
+		`(?m)^\s*[\w-]+:\s*[^;]+;\s*$`,
+		`(?m)^\s*@(media|import|font-face)\b`,
+	)},
+	{language: "yaml", minScore: 2, patterns: compile(
+		`(?m)^---\s*$`,
+		`(?m)^[\w.-]+:\s*$`,
+		`(?m)^\s*- [\w.-]+:\s`,
+		`(?m)^[\w.-]+: \S`,
+	)},
+}
+
+func compile(patterns ...string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		res[i] = regexp.MustCompile(p)
+	}
+	return res
+}
@@ -0,0 +1,17 @@
+package language
+
+import "regexp"
+
+// language describes a supported language and the extensions it uses.
+// The first extension is the one used when generating file names.
+type language struct {
+	extensions []string
+}
+
+// heuristic matches content against a set of patterns. A language is only
+// considered when at least minScore of its patterns match.
+type heuristic struct {
+	language string
+	minScore int
+	patterns []*regexp.Regexp
+}
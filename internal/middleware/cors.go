@@ -17,7 +17,7 @@ func CORS(allowedOrigins []string, allowCredentials bool) Middleware {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 			}
 
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 			if allowCredentials {
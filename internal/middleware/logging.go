@@ -31,3 +31,9 @@ func Logging(logger *log.Logger) Middleware {
 		})
 	}
 }
+
+// Unwrap returns the wrapped writer, so handlers can reach it through an
+// http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -17,3 +17,17 @@ func Security() Middleware {
 		})
 	}
 }
+
+// Embeddable returns middleware that relaxes the framing restrictions set by
+// Security so a route can be loaded in an iframe on any site.
+func Embeddable() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Del("X-Frame-Options")
+			w.Header().Set("Content-Security-Policy",
+				"default-src 'none'; style-src 'self'; img-src 'self' data:; base-uri 'none'; frame-ancestors *")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// OpenCheckpoint opens the checkpoint file at path, creating it if needed,
+// and loads the steps it records.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			done[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	return &Checkpoint{file: file, done: done}, nil
+}
+
+// Done reports whether a step was completed.
+func (c *Checkpoint) Done(step string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[step]
+}
+
+// Mark records that a step was completed.
+func (c *Checkpoint) Mark(step string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done[step] {
+		return nil
+	}
+	if _, err := c.file.WriteString(step + "\n"); err != nil {
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	c.done[step] = true
+	return nil
+}
+
+// Close closes the checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+func gistStep(id string) string {
+	return "gist " + id
+}
@@ -0,0 +1,393 @@
+// Package migrate copies gist data between backends.
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/storage"
+)
+
+// Steps recorded once a whole phase of the migration is complete.
+const (
+	phaseGists      = "phase gists"
+	phaseComments   = "phase comments"
+	phaseStars      = "phase stars"
+	phaseTokens     = "phase tokens"
+	phaseWebhooks   = "phase webhooks"
+	phaseDeliveries = "phase deliveries"
+	phaseExports    = "phase exports"
+)
+
+// exportStorageID mirrors where the server stores export archives.
+const exportStorageID = "_exports"
+
+// revisionBatchSize keeps the revisions written in one transaction well
+// below Firestore's limit of 500 writes.
+const revisionBatchSize = 100
+
+// New creates a Migrator copying from src and srcFiles to dst and dstFiles
+// with the given number of parallel workers.
+func New(src Store, srcFiles storage.FileStorage, dst Store, dstFiles storage.FileStorage, checkpoint *Checkpoint, workers int, infoLog *log.Logger) *Migrator {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Migrator{
+		src:        src,
+		srcFiles:   srcFiles,
+		dst:        dst,
+		dstFiles:   dstFiles,
+		checkpoint: checkpoint,
+		workers:    workers,
+		infoLog:    infoLog,
+	}
+}
+
+// Run copies every gist that is not recorded in the checkpoint yet, then
+// every comment and star of the copied gists, and finally the users' API
+// tokens, webhooks with their delivery logs, and exports with their
+// archives. Writes made to the source while it runs may be missed, so the
+// source should not be in use.
+func (m *Migrator) Run(ctx context.Context) (Stats, error) {
+	var stats Stats
+
+	err := m.runPhase(ctx, phaseGists, "gists", &stats.Gists, func(ctx context.Context, do func(task) error) error {
+		return m.src.Walk(ctx, func(g *model.Gist) error {
+			if m.checkpoint.Done(gistStep(g.ID)) {
+				atomic.AddInt64(&stats.SkippedGists, 1)
+				return nil
+			}
+			return do(func(ctx context.Context) error {
+				return m.copyGist(ctx, g, &stats)
+			})
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = m.runPhase(ctx, phaseComments, "comments", &stats.Comments, func(ctx context.Context, do func(task) error) error {
+		return m.src.WalkComments(ctx, func(c *model.Comment) error {
+			if !m.checkpoint.Done(gistStep(c.GistID)) {
+				return nil
+			}
+			return do(func(ctx context.Context) error {
+				if err := m.dst.ImportComment(ctx, c); err != nil {
+					return fmt.Errorf("comment %s of gist %s: %w", c.ID, c.GistID, err)
+				}
+				atomic.AddInt64(&stats.Comments, 1)
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = m.runPhase(ctx, phaseStars, "stars", &stats.Stars, func(ctx context.Context, do func(task) error) error {
+		return m.src.WalkStars(ctx, func(s *model.Star) error {
+			if !m.checkpoint.Done(gistStep(s.GistID)) {
+				return nil
+			}
+			return do(func(ctx context.Context) error {
+				if err := m.dst.ImportStar(ctx, s); err != nil {
+					return fmt.Errorf("star of gist %s by %s: %w", s.GistID, s.UserID, err)
+				}
+				atomic.AddInt64(&stats.Stars, 1)
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = m.runPhase(ctx, phaseTokens, "API tokens", &stats.Tokens, func(ctx context.Context, do func(task) error) error {
+		return m.src.WalkTokens(ctx, func(t *model.APIToken) error {
+			return do(func(ctx context.Context) error {
+				if err := m.dst.ImportToken(ctx, t); err != nil {
+					return fmt.Errorf("token %s: %w", t.ID, err)
+				}
+				atomic.AddInt64(&stats.Tokens, 1)
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = m.runPhase(ctx, phaseWebhooks, "webhooks", &stats.Webhooks, func(ctx context.Context, do func(task) error) error {
+		return m.src.WalkWebhooks(ctx, func(w *model.Webhook) error {
+			return do(func(ctx context.Context) error {
+				if err := m.dst.ImportWebhook(ctx, w); err != nil {
+					return fmt.Errorf("webhook %s: %w", w.ID, err)
+				}
+				atomic.AddInt64(&stats.Webhooks, 1)
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = m.runPhase(ctx, phaseDeliveries, "webhook deliveries", &stats.Deliveries, func(ctx context.Context, do func(task) error) error {
+		return m.src.WalkDeliveries(ctx, func(d *model.WebhookDelivery) error {
+			return do(func(ctx context.Context) error {
+				if err := m.dst.ImportDelivery(ctx, d); err != nil {
+					return fmt.Errorf("delivery %s: %w", d.ID, err)
+				}
+				atomic.AddInt64(&stats.Deliveries, 1)
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = m.runPhase(ctx, phaseExports, "exports", &stats.Exports, func(ctx context.Context, do func(task) error) error {
+		return m.src.WalkExports(ctx, func(e *model.Export) error {
+			return do(func(ctx context.Context) error {
+				return m.copyExport(ctx, e, &stats)
+			})
+		})
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// runPhase runs a phase of the migration unless the checkpoint records it
+// as complete, and logs how many records it copied.
+func (m *Migrator) runPhase(ctx context.Context, phase, records string, copied *int64, walk func(ctx context.Context, do func(task) error) error) error {
+	if m.checkpoint.Done(phase) {
+		return nil
+	}
+
+	if err := m.parallel(ctx, walk); err != nil {
+		return err
+	}
+	if err := m.checkpoint.Mark(phase); err != nil {
+		return err
+	}
+
+	m.infoLog.Printf("copied %d %s", atomic.LoadInt64(copied), records)
+	return nil
+}
+
+// Pending returns how many gists of the source have not been copied yet.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	pending := 0
+	err := m.src.Walk(ctx, func(g *model.Gist) error {
+		if !m.checkpoint.Done(gistStep(g.ID)) {
+			pending++
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// copyGist copies a gist with its revisions and stored files. Files are
+// copied first so that the copied gist never refers to a missing file; a
+// file already missing from the source is reported and skipped.
+func (m *Migrator) copyGist(ctx context.Context, g *model.Gist, stats *Stats) error {
+	revisions, err := m.src.ListRevisions(ctx, g.ID)
+	if err != nil {
+		return fmt.Errorf("gist %s: %w", g.ID, err)
+	}
+
+	for _, name := range g.StoredFiles(revisions) {
+		info, err := m.copyFile(ctx, g.ID, name)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			m.infoLog.Printf("gist %s: file %s is missing from the source", g.ID, name)
+			atomic.AddInt64(&stats.MissingFiles, 1)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gist %s: file %s: %w", g.ID, name, err)
+		}
+		atomic.AddInt64(&stats.Files, 1)
+		atomic.AddInt64(&stats.Bytes, info.Size)
+
+		// The download URL is specific to the storage the file is in.
+		if name == g.FileName {
+			g.FileURL = info.FileURL
+			g.PublicFileURL = info.PublicFileURL
+		}
+	}
+
+	if err := m.dst.ImportGist(ctx, g); err != nil {
+		return fmt.Errorf("gist %s: %w", g.ID, err)
+	}
+
+	copied, err := m.copyRevisions(ctx, g.ID, revisions)
+	if err != nil {
+		return fmt.Errorf("gist %s: %w", g.ID, err)
+	}
+
+	if err := m.checkpoint.Mark(gistStep(g.ID)); err != nil {
+		return err
+	}
+	atomic.AddInt64(&stats.Gists, 1)
+	atomic.AddInt64(&stats.Revisions, int64(copied))
+	return nil
+}
+
+// copyExport copies an export with its archive. Like gist files, the
+// archive is copied first, and one missing from the source is reported and
+// skipped.
+func (m *Migrator) copyExport(ctx context.Context, e *model.Export, stats *Stats) error {
+	if e.FileName != "" {
+		info, err := m.copyFile(ctx, exportStorageID, e.FileName)
+		switch {
+		case apperror.Is(err, apperror.CodeNotFound):
+			m.infoLog.Printf("export %s: archive %s is missing from the source", e.ID, e.FileName)
+			atomic.AddInt64(&stats.MissingFiles, 1)
+		case err != nil:
+			return fmt.Errorf("export %s: archive %s: %w", e.ID, e.FileName, err)
+		default:
+			atomic.AddInt64(&stats.Files, 1)
+			atomic.AddInt64(&stats.Bytes, info.Size)
+		}
+	}
+
+	if err := m.dst.ImportExport(ctx, e); err != nil {
+		return fmt.Errorf("export %s: %w", e.ID, err)
+	}
+	atomic.AddInt64(&stats.Exports, 1)
+	return nil
+}
+
+// copyRevisions copies the revisions that the destination does not have
+// yet, which are the ones numbered after its latest revision, and returns
+// how many it copied.
+func (m *Migrator) copyRevisions(ctx context.Context, gistID string, revisions []*model.Revision) (int, error) {
+	existing, err := m.dst.ListRevisions(ctx, gistID)
+	if err != nil {
+		return 0, err
+	}
+
+	latest := 0
+	for _, rev := range existing {
+		latest = max(latest, rev.Number)
+	}
+
+	var missing []*model.Revision
+	for _, rev := range revisions {
+		if rev.Number > latest {
+			missing = append(missing, rev)
+		}
+	}
+
+	for start := 0; start < len(missing); start += revisionBatchSize {
+		end := min(start+revisionBatchSize, len(missing))
+		if err := m.dst.CreateRevisions(ctx, gistID, missing[start:end]); err != nil {
+			return start, err
+		}
+	}
+
+	return len(missing), nil
+}
+
+// copyFile copies a stored file and checks that the copy has the same
+// SHA-256 checksum as the data read from the source.
+func (m *Migrator) copyFile(ctx context.Context, gistID, name string) (*storage.FileInfo, error) {
+	reader, info, err := m.srcFiles.Open(ctx, gistID, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	copied, err := m.dstFiles.Upload(ctx, gistID, name, io.TeeReader(reader, hash), info.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := checksum(ctx, m.dstFiles, gistID, name)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(sum, hash.Sum(nil)) {
+		return nil, fmt.Errorf("checksum mismatch after copy")
+	}
+
+	return copied, nil
+}
+
+// checksum returns the SHA-256 checksum of a stored file.
+func checksum(ctx context.Context, files storage.FileStorage, gistID, name string) ([]byte, error) {
+	reader, _, err := files.Open(ctx, gistID, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return nil, apperror.Storage(err)
+	}
+	return hash.Sum(nil), nil
+}
+
+// task is a unit of work run by a migration worker.
+type task func(ctx context.Context) error
+
+// parallel runs the tasks that walk passes to do on m.workers workers. The
+// first failing task cancels the walk and the remaining tasks, and its
+// error is returned.
+func (m *Migrator) parallel(ctx context.Context, walk func(ctx context.Context, do func(task) error) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failure  error
+	)
+	tasks := make(chan task)
+
+	for i := 0; i < m.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				if err := t(ctx); err != nil {
+					failOnce.Do(func() {
+						failure = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	err := walk(ctx, func(t task) error {
+		select {
+		case tasks <- t:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(tasks)
+	wg.Wait()
+
+	if failure != nil {
+		return failure
+	}
+	return err
+}
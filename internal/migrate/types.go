@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"log"
+	"os"
+	"sync"
+
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+	"github.com/abhisheksharm-3/quickgist/internal/storage"
+)
+
+// Store is a backend that gist data is copied from or to.
+type Store interface {
+	repository.GistRepository
+	repository.RevisionRepository
+	repository.StarRepository
+	repository.MigrationRepository
+}
+
+// Migrator copies gists with their revisions, comments, stars and stored
+// files, along with API tokens, webhooks, webhook deliveries and exports,
+// from one backend to another, keeping their IDs and timestamps.
+// Copied gists are recorded in a checkpoint so that an interrupted
+// migration resumes where it stopped.
+type Migrator struct {
+	src        Store
+	srcFiles   storage.FileStorage
+	dst        Store
+	dstFiles   storage.FileStorage
+	checkpoint *Checkpoint
+	workers    int
+	infoLog    *log.Logger
+}
+
+// Stats counts what a migration copied.
+type Stats struct {
+	Gists        int64 `json:"gists"`
+	SkippedGists int64 `json:"skippedGists"`
+	Revisions    int64 `json:"revisions"`
+	Comments     int64 `json:"comments"`
+	Stars        int64 `json:"stars"`
+	Tokens       int64 `json:"tokens"`
+	Webhooks     int64 `json:"webhooks"`
+	Deliveries   int64 `json:"deliveries"`
+	Exports      int64 `json:"exports"`
+	Files        int64 `json:"files"`
+	Bytes        int64 `json:"bytes"`
+	MissingFiles int64 `json:"missingFiles"`
+}
+
+// Counts is the number of records stored in a backend.
+type Counts struct {
+	Gists      int64 `json:"gists"`
+	Revisions  int64 `json:"revisions"`
+	Comments   int64 `json:"comments"`
+	Stars      int64 `json:"stars"`
+	Tokens     int64 `json:"tokens"`
+	Webhooks   int64 `json:"webhooks"`
+	Deliveries int64 `json:"deliveries"`
+	Exports    int64 `json:"exports"`
+}
+
+// Report is the result of verifying a migration. Mismatches describes
+// every difference found between the sampled gists.
+type Report struct {
+	Source      Counts   `json:"source"`
+	Destination Counts   `json:"destination"`
+	Sampled     int      `json:"sampled"`
+	Mismatches  []string `json:"mismatches"`
+}
+
+// OK reports whether the destination matched the source.
+func (r *Report) OK() bool {
+	return r.Source == r.Destination && len(r.Mismatches) == 0
+}
+
+// Checkpoint records the completed steps of a migration in an append-only
+// file, one per line.
+type Checkpoint struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[string]bool
+}
@@ -0,0 +1,262 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+// Verify compares the number of records in both backends and checks that
+// up to samples randomly chosen gists of the source were copied with
+// their revisions, star counts and files intact.
+func (m *Migrator) Verify(ctx context.Context, samples int) (*Report, error) {
+	report := &Report{Mismatches: []string{}}
+
+	var sampled []*model.Gist
+	seen := 0
+	source, err := m.count(ctx, m.src, func(g *model.Gist) {
+		// Reservoir sampling keeps every gist equally likely to be chosen
+		// without holding them all.
+		seen++
+		if len(sampled) < samples {
+			sampled = append(sampled, g)
+		} else if i := rand.IntN(seen); i < samples {
+			sampled[i] = g
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("counting source: %w", err)
+	}
+	report.Source = source
+
+	destination, err := m.count(ctx, m.dst, func(*model.Gist) {})
+	if err != nil {
+		return nil, fmt.Errorf("counting destination: %w", err)
+	}
+	report.Destination = destination
+
+	var mu sync.Mutex
+	err = m.parallel(ctx, func(ctx context.Context, do func(task) error) error {
+		for _, g := range sampled {
+			err := do(func(ctx context.Context) error {
+				mismatches, err := m.compareGist(ctx, g)
+				if err != nil {
+					return fmt.Errorf("gist %s: %w", g.ID, err)
+				}
+				mu.Lock()
+				report.Mismatches = append(report.Mismatches, mismatches...)
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Sampled = len(sampled)
+	sort.Strings(report.Mismatches)
+	return report, nil
+}
+
+// count counts the records of a backend, passing every gist to visit.
+func (m *Migrator) count(ctx context.Context, store Store, visit func(*model.Gist)) (Counts, error) {
+	var counts Counts
+
+	err := m.parallel(ctx, func(ctx context.Context, do func(task) error) error {
+		return store.Walk(ctx, func(g *model.Gist) error {
+			counts.Gists++
+			visit(g)
+			return do(func(ctx context.Context) error {
+				revisions, err := store.ListRevisions(ctx, g.ID)
+				if err != nil {
+					return err
+				}
+				atomic.AddInt64(&counts.Revisions, int64(len(revisions)))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	err = store.WalkComments(ctx, func(*model.Comment) error {
+		counts.Comments++
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	err = store.WalkStars(ctx, func(*model.Star) error {
+		counts.Stars++
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	err = store.WalkTokens(ctx, func(*model.APIToken) error {
+		counts.Tokens++
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	err = store.WalkWebhooks(ctx, func(*model.Webhook) error {
+		counts.Webhooks++
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	err = store.WalkDeliveries(ctx, func(*model.WebhookDelivery) error {
+		counts.Deliveries++
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	err = store.WalkExports(ctx, func(*model.Export) error {
+		counts.Exports++
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	return counts, nil
+}
+
+// compareGist describes every difference between a source gist and its
+// copy.
+func (m *Migrator) compareGist(ctx context.Context, g *model.Gist) ([]string, error) {
+	copied, err := m.dst.Get(ctx, g.ID)
+	if apperror.Is(err, apperror.CodeNotFound) {
+		return []string{fmt.Sprintf("gist %s: missing", g.ID)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []string
+	mismatch := func(format string, args ...interface{}) {
+		mismatches = append(mismatches, fmt.Sprintf("gist %s: ", g.ID)+fmt.Sprintf(format, args...))
+	}
+
+	// File URLs are given by the storage holding the file, so they are
+	// expected to differ.
+	want, got := g.ToMap(), copied.ToMap()
+	for _, field := range []string{"fileURL", "publicFileURL"} {
+		delete(want, field)
+		delete(got, field)
+	}
+	for _, field := range differentFields(want, got) {
+		mismatch("field %s differs", field)
+	}
+	if g.ViewCount != copied.ViewCount || g.DownloadCount != copied.DownloadCount {
+		mismatch("counters differ")
+	}
+
+	revisions, err := m.src.ListRevisions(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	copiedRevisions, err := m.dst.ListRevisions(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) != len(copiedRevisions) {
+		mismatch("has %d revisions instead of %d", len(copiedRevisions), len(revisions))
+	} else {
+		for i, rev := range revisions {
+			if len(differentFields(rev.ToMap(), copiedRevisions[i].ToMap())) > 0 {
+				mismatch("revision %d differs", rev.Number)
+			}
+		}
+	}
+
+	stars, err := m.src.StarCount(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	copiedStars, err := m.dst.StarCount(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	if stars != copiedStars {
+		mismatch("has %d stars instead of %d", copiedStars, stars)
+	}
+
+	for _, name := range g.StoredFiles(revisions) {
+		want, err := checksum(ctx, m.srcFiles, g.ID, name)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		got, err := checksum(ctx, m.dstFiles, g.ID, name)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			mismatch("file %s is missing", name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(want, got) {
+			mismatch("file %s has a different checksum", name)
+		}
+	}
+
+	return mismatches, nil
+}
+
+// differentFields returns the sorted names of the fields that differ
+// between two stored records. Timestamps are compared by instant since
+// backends may return them in different locations.
+func differentFields(a, b map[string]interface{}) []string {
+	var fields []string
+	for key, av := range a {
+		bv, ok := b[key]
+		if !ok {
+			fields = append(fields, key)
+			continue
+		}
+		if at, ok := av.(time.Time); ok {
+			if bt, ok := bv.(time.Time); !ok || !at.Equal(bt) {
+				fields = append(fields, key)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(av, bv) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+
+	sort.Strings(fields)
+	return fields
+}
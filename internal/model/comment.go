@@ -0,0 +1,119 @@
+package model
+
+import "time"
+
+// Comment is a Markdown comment on a gist, optionally anchored to a range
+// of lines and replying to another comment.
+type Comment struct {
+	ID        string
+	GistID    string
+	ParentID  string
+	UserID    string
+	Body      string
+	Anchor    *CommentAnchor
+	Deleted   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CommentAnchor ties a comment to lines of a gist file at a revision.
+type CommentAnchor struct {
+	FileName  string
+	StartLine int
+	EndLine   int
+	Revision  string
+}
+
+// NewComment creates a new Comment with the current timestamp.
+func NewComment(gistID, userID, body string) *Comment {
+	return &Comment{
+		GistID:    gistID,
+		UserID:    userID,
+		Body:      body,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// WithParent marks the comment as a reply to another comment.
+func (c *Comment) WithParent(parentID string) *Comment {
+	c.ParentID = parentID
+	return c
+}
+
+// WithAnchor anchors the comment to a range of lines.
+func (c *Comment) WithAnchor(anchor *CommentAnchor) *Comment {
+	c.Anchor = anchor
+	return c
+}
+
+// ToMap converts the comment to a map for Firestore storage.
+func (c *Comment) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"gistId":    c.GistID,
+		"userId":    c.UserID,
+		"body":      c.Body,
+		"deleted":   c.Deleted,
+		"createdAt": c.CreatedAt,
+	}
+
+	if c.ParentID != "" {
+		m["parentId"] = c.ParentID
+	}
+	if c.Anchor != nil {
+		m["anchor"] = map[string]interface{}{
+			"fileName":  c.Anchor.FileName,
+			"startLine": int64(c.Anchor.StartLine),
+			"endLine":   int64(c.Anchor.EndLine),
+			"revision":  c.Anchor.Revision,
+		}
+	}
+	if !c.UpdatedAt.IsZero() {
+		m["updatedAt"] = c.UpdatedAt
+	}
+
+	return m
+}
+
+// CommentFromMap creates a Comment from Firestore document data.
+func CommentFromMap(id string, data map[string]interface{}) *Comment {
+	c := &Comment{ID: id}
+
+	if v, ok := data["gistId"].(string); ok {
+		c.GistID = v
+	}
+	if v, ok := data["parentId"].(string); ok {
+		c.ParentID = v
+	}
+	if v, ok := data["userId"].(string); ok {
+		c.UserID = v
+	}
+	if v, ok := data["body"].(string); ok {
+		c.Body = v
+	}
+	if v, ok := data["anchor"].(map[string]interface{}); ok {
+		c.Anchor = &CommentAnchor{}
+		if s, ok := v["fileName"].(string); ok {
+			c.Anchor.FileName = s
+		}
+		if n, ok := v["startLine"].(int64); ok {
+			c.Anchor.StartLine = int(n)
+		}
+		if n, ok := v["endLine"].(int64); ok {
+			c.Anchor.EndLine = int(n)
+		}
+		if s, ok := v["revision"].(string); ok {
+			c.Anchor.Revision = s
+		}
+	}
+	if v, ok := data["deleted"].(bool); ok {
+		c.Deleted = v
+	}
+	if v, ok := data["createdAt"].(time.Time); ok {
+		c.CreatedAt = v
+	}
+	if v, ok := data["updatedAt"].(time.Time); ok {
+		c.UpdatedAt = v
+	}
+
+	return c
+}
@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// ExportStatus tracks the progress of a data export.
+type ExportStatus string
+
+const (
+	// ExportPending exports are still being built.
+	ExportPending ExportStatus = "pending"
+	// ExportComplete exports have an archive ready for download.
+	ExportComplete ExportStatus = "complete"
+	// ExportFailed exports could not be built.
+	ExportFailed ExportStatus = "failed"
+)
+
+// Export is an archive of everything a user stored, built in the
+// background and downloaded once complete.
+type Export struct {
+	ID          string
+	UserID      string
+	Status      ExportStatus
+	FileName    string
+	Size        int64
+	Gists       int
+	Revisions   int
+	Attachments int
+	Error       string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+}
+
+// NewExport creates a pending export with the current timestamp.
+func NewExport(userID string) *Export {
+	return &Export{
+		UserID:    userID,
+		Status:    ExportPending,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// ToMap converts the export to a map for Firestore storage.
+func (e *Export) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"userId":      e.UserID,
+		"status":      string(e.Status),
+		"fileName":    e.FileName,
+		"size":        e.Size,
+		"gists":       e.Gists,
+		"revisions":   e.Revisions,
+		"attachments": e.Attachments,
+		"error":       e.Error,
+		"createdAt":   e.CreatedAt,
+	}
+
+	if !e.CompletedAt.IsZero() {
+		m["completedAt"] = e.CompletedAt
+	}
+
+	return m
+}
+
+// ExportFromMap creates an Export from Firestore document data.
+func ExportFromMap(id string, data map[string]interface{}) *Export {
+	e := &Export{ID: id}
+
+	if v, ok := data["userId"].(string); ok {
+		e.UserID = v
+	}
+	if v, ok := data["status"].(string); ok {
+		e.Status = ExportStatus(v)
+	}
+	if v, ok := data["fileName"].(string); ok {
+		e.FileName = v
+	}
+	if v, ok := data["size"].(int64); ok {
+		e.Size = v
+	}
+	if v, ok := data["gists"].(int64); ok {
+		e.Gists = int(v)
+	}
+	if v, ok := data["revisions"].(int64); ok {
+		e.Revisions = int(v)
+	}
+	if v, ok := data["attachments"].(int64); ok {
+		e.Attachments = int(v)
+	}
+	if v, ok := data["error"].(string); ok {
+		e.Error = v
+	}
+	if v, ok := data["createdAt"].(time.Time); ok {
+		e.CreatedAt = v
+	}
+	if v, ok := data["completedAt"].(time.Time); ok {
+		e.CompletedAt = v
+	}
+
+	return e
+}
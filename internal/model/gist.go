@@ -1,6 +1,56 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abhisheksharm-3/quickgist/internal/language"
+)
+
+const (
+	maxFileNameBase = 64
+	excerptLines    = 5
+	excerptBytes    = 512
+)
+
+// Tag limits.
+const (
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+var (
+	unsafeFileNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+	unsafeTagChars      = regexp.MustCompile(`[^a-z0-9+#._-]+`)
+)
+
+// ErrTooManyTags is returned when a gist is given more than MaxTags tags.
+var ErrTooManyTags = fmt.Errorf("a gist can have at most %d tags", MaxTags)
+
+// Visibility controls who can see a gist.
+type Visibility string
+
+const (
+	// VisibilityPublic gists can be listed and viewed by anyone.
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted gists can be viewed by anyone with the link.
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate gists can only be viewed by their owner.
+	VisibilityPrivate Visibility = "private"
+)
+
+// ParseVisibility converts a string into a Visibility, reporting false for
+// unknown values.
+func ParseVisibility(s string) (Visibility, bool) {
+	switch v := Visibility(s); v {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return v, true
+	}
+	return "", false
+}
 
 // Gist represents a code snippet or file share.
 type Gist struct {
@@ -8,23 +58,75 @@ type Gist struct {
 	Title         string
 	Description   string
 	Content       string
+	Excerpt       string
+	Size          int64
+	Language      string
+	Tags          []string
 	IsDraft       bool
+	Visibility    Visibility
 	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ExpiresAt     time.Time
 	UserID        string
 	FileName      string
 	FileURL       string
 	PublicFileURL string
+	FileSHA256    string
+	ViewCount     int64
+	DownloadCount int64
+
+	// Stars is the star count, which is summed from counter shards rather
+	// than stored with the gist. It is nil until loaded.
+	Stars *int64
+}
+
+// GistCounts holds increments to the usage counters of a gist.
+type GistCounts struct {
+	Views     int64
+	Downloads int64
 }
 
 // NewGist creates a new Gist with the current timestamp.
 func NewGist(title, description, content string, isDraft bool) *Gist {
-	return &Gist{
+	g := &Gist{
 		Title:       title,
 		Description: description,
-		Content:     content,
 		IsDraft:     isDraft,
+		Visibility:  VisibilityPublic,
 		CreatedAt:   time.Now().UTC(),
 	}
+	g.SetContent(content)
+	return g
+}
+
+// SetContent replaces the gist content and the summary derived from it.
+func (g *Gist) SetContent(content string) {
+	g.Content = content
+	g.Excerpt = Excerpt(content)
+	g.Size = int64(len(content))
+}
+
+// Excerpt returns the first few lines of content, truncated on a rune
+// boundary so that listings can preview a gist without loading it whole.
+func Excerpt(content string) string {
+	end := 0
+	for i := 0; i < excerptLines && end < len(content); i++ {
+		next := strings.IndexByte(content[end:], '\n')
+		if next < 0 {
+			end = len(content)
+			break
+		}
+		end += next + 1
+	}
+
+	if end > excerptBytes {
+		end = excerptBytes
+		for end > 0 && !utf8.RuneStart(content[end]) {
+			end--
+		}
+	}
+
+	return strings.TrimRight(content[:end], "\r\n")
 }
 
 // WithUser sets the user ID for the gist.
@@ -33,39 +135,190 @@ func (g *Gist) WithUser(userID string) *Gist {
 	return g
 }
 
-// WithFile sets file information for the gist.
+// WithLanguage sets the language identifier for the gist.
+func (g *Gist) WithLanguage(language string) *Gist {
+	g.Language = language
+	return g
+}
+
+// WithVisibility sets who can see the gist.
+func (g *Gist) WithVisibility(visibility Visibility) *Gist {
+	g.Visibility = visibility
+	return g
+}
+
+// WithExpiry makes the gist expire at the given time.
+func (g *Gist) WithExpiry(expiresAt time.Time) *Gist {
+	g.ExpiresAt = expiresAt
+	return g
+}
+
+// Expired reports whether the gist expired by now. Expired gists are
+// hidden until they are purged.
+func (g *Gist) Expired(now time.Time) bool {
+	return !g.ExpiresAt.IsZero() && !now.Before(g.ExpiresAt)
+}
+
+// WithTags sets the tags of the gist. Tags should already be normalized.
+func (g *Gist) WithTags(tags []string) *Gist {
+	g.Tags = tags
+	return g
+}
+
+// NormalizeTag lowercases a tag and replaces characters outside letters,
+// digits and "+#._-" with hyphens. It returns an empty string for tags
+// with nothing left.
+func NormalizeTag(tag string) (string, error) {
+	tag = unsafeTagChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(tag)), "-")
+	tag = strings.Trim(tag, "-")
+	if len(tag) > MaxTagLength {
+		return "", fmt.Errorf("tags must be at most %d characters", MaxTagLength)
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes each tag, dropping empty tags and duplicates
+// while keeping the original order.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		tag, err := NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxTags {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
+
+// CanView reports whether the given user may see the gist. Only owners can
+// see private gists, so userID must be an authenticated identity and never
+// one the client merely claims.
+func (g *Gist) CanView(userID string) bool {
+	if g.Visibility != VisibilityPrivate {
+		return true
+	}
+	return g.UserID != "" && g.UserID == userID
+}
+
+// WithFile sets file information for the gist. The checksum of a
+// previous attachment is cleared.
 func (g *Gist) WithFile(fileName, fileURL, publicFileURL string) *Gist {
 	g.FileName = fileName
 	g.FileURL = fileURL
 	g.PublicFileURL = publicFileURL
+	g.FileSHA256 = ""
+	return g
+}
+
+// WithFileChecksum sets the hex encoded SHA-256 checksum of the attachment.
+func (g *Gist) WithFileChecksum(sum string) *Gist {
+	g.FileSHA256 = sum
 	return g
 }
 
-// ToMap converts the gist to a map for Firestore storage.
+// StoredFiles returns the names of the stored files of a gist with the
+// given revisions: its current attachment and the stored copies of the
+// attachments of its revisions.
+func (g *Gist) StoredFiles(revisions []*Revision) []string {
+	var names []string
+	if g.FileName != "" {
+		names = append(names, g.FileName)
+	}
+
+	seen := make(map[string]bool)
+	for _, rev := range revisions {
+		if rev.AttachmentHash == "" || seen[rev.AttachmentHash] {
+			continue
+		}
+		seen[rev.AttachmentHash] = true
+		names = append(names, RevisionObject(rev.AttachmentHash))
+	}
+	return names
+}
+
+// ResolvedLanguage returns the stored language of the gist, detecting it
+// for documents written before languages were recorded.
+func (g *Gist) ResolvedLanguage() string {
+	if g.Language != "" {
+		return g.Language
+	}
+	return language.Detect(g.FileName, g.Content)
+}
+
+// ContentFileName returns the file name used when the gist content is
+// exported as a file, derived from the title and language.
+func (g *Gist) ContentFileName() string {
+	base := unsafeFileNameChars.ReplaceAllString(strings.ToLower(g.Title), "-")
+	base = strings.Trim(base, ".-_")
+	if len(base) > maxFileNameBase {
+		base = strings.TrimRight(base[:maxFileNameBase], ".-_")
+	}
+	if base == "" {
+		base = "gist"
+	}
+
+	name := base + language.Extension(g.ResolvedLanguage())
+	if name == g.FileName {
+		name = "content-" + name
+	}
+	return name
+}
+
+// ToMap converts the gist to a map for Firestore storage. Usage counters
+// are left out because they are only ever changed by increments.
 func (g *Gist) ToMap() map[string]interface{} {
+	// Tags are always written as an array so that array-contains queries
+	// and merges that clear every tag behave consistently.
+	tags := g.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
 	m := map[string]interface{}{
 		"title":       g.Title,
 		"description": g.Description,
 		"content":     g.Content,
+		"excerpt":     Excerpt(g.Content),
+		"size":        int64(len(g.Content)),
+		"language":    g.Language,
+		"tags":        tags,
 		"isDraft":     g.IsDraft,
+		"visibility":  string(g.Visibility),
 		"createdAt":   g.CreatedAt,
 	}
 
+	if !g.UpdatedAt.IsZero() {
+		m["updatedAt"] = g.UpdatedAt
+	}
+	if !g.ExpiresAt.IsZero() {
+		m["expiresAt"] = g.ExpiresAt
+	}
 	if g.UserID != "" {
 		m["userId"] = g.UserID
 	}
-	if g.FileName != "" {
-		m["fileName"] = g.FileName
-		m["fileURL"] = g.FileURL
-		m["publicFileURL"] = g.PublicFileURL
-	}
+	// File fields are written even when empty so that merging an update
+	// removes a dropped attachment.
+	m["fileName"] = g.FileName
+	m["fileURL"] = g.FileURL
+	m["publicFileURL"] = g.PublicFileURL
+	m["fileSha256"] = g.FileSHA256
 
 	return m
 }
 
 // GistFromMap creates a Gist from Firestore document data.
 func GistFromMap(id string, data map[string]interface{}) *Gist {
-	g := &Gist{ID: id}
+	g := &Gist{ID: id, Visibility: VisibilityPublic}
 
 	if v, ok := data["title"].(string); ok {
 		g.Title = v
@@ -74,14 +327,41 @@ func GistFromMap(id string, data map[string]interface{}) *Gist {
 		g.Description = v
 	}
 	if v, ok := data["content"].(string); ok {
-		g.Content = v
+		g.SetContent(v)
+	}
+	if v, ok := data["excerpt"].(string); ok {
+		g.Excerpt = v
+	}
+	if v, ok := data["size"].(int64); ok {
+		g.Size = v
+	}
+	if v, ok := data["language"].(string); ok {
+		g.Language = v
+	}
+	if v, ok := data["tags"].([]interface{}); ok {
+		for _, t := range v {
+			if tag, ok := t.(string); ok {
+				g.Tags = append(g.Tags, tag)
+			}
+		}
 	}
 	if v, ok := data["isDraft"].(bool); ok {
 		g.IsDraft = v
 	}
+	if v, ok := data["visibility"].(string); ok {
+		if visibility, ok := ParseVisibility(v); ok {
+			g.Visibility = visibility
+		}
+	}
 	if v, ok := data["createdAt"].(time.Time); ok {
 		g.CreatedAt = v
 	}
+	if v, ok := data["updatedAt"].(time.Time); ok {
+		g.UpdatedAt = v
+	}
+	if v, ok := data["expiresAt"].(time.Time); ok {
+		g.ExpiresAt = v
+	}
 	if v, ok := data["userId"].(string); ok {
 		g.UserID = v
 	}
@@ -94,6 +374,47 @@ func GistFromMap(id string, data map[string]interface{}) *Gist {
 	if v, ok := data["publicFileURL"].(string); ok {
 		g.PublicFileURL = v
 	}
+	if v, ok := data["fileSha256"].(string); ok {
+		g.FileSHA256 = v
+	}
+	if v, ok := data["viewCount"].(int64); ok {
+		g.ViewCount = v
+	}
+	if v, ok := data["downloadCount"].(int64); ok {
+		g.DownloadCount = v
+	}
 
 	return g
 }
+
+// StaleDerivedFields returns the derived and defaulted fields of a stored
+// gist document that are missing or out of date, mapped to their
+// recomputed values.
+func StaleDerivedFields(data map[string]interface{}) map[string]interface{} {
+	content, _ := data["content"].(string)
+	stale := make(map[string]interface{})
+
+	if v, ok := data["excerpt"].(string); !ok || v != Excerpt(content) {
+		stale["excerpt"] = Excerpt(content)
+	}
+	if v, ok := data["size"].(int64); !ok || v != int64(len(content)) {
+		stale["size"] = int64(len(content))
+	}
+
+	// Gists from before visibility was stored are public, but queries
+	// filtering on it skip documents without the field.
+	if v, _ := data["visibility"].(string); v == "" {
+		stale["visibility"] = string(VisibilityPublic)
+	}
+
+	// A stored language may have been chosen by the user, so only a
+	// missing one is detected.
+	if v, _ := data["language"].(string); v == "" {
+		fileName, _ := data["fileName"].(string)
+		if lang := language.Detect(fileName, content); lang != "" {
+			stale["language"] = lang
+		}
+	}
+
+	return stale
+}
@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+// RevisionObjectPrefix names the stored copies of revision attachments,
+// which are addressed by blob ID so that unchanged attachments are stored
+// once.
+const RevisionObjectPrefix = "revisions/"
+
+// Revision is a recorded state of a gist's files, numbered from 1 in the
+// order they were made. Revisions back the Git history of a gist.
+type Revision struct {
+	ID              string
+	GistID          string
+	Number          int
+	UserID          string
+	Message         string
+	ContentFileName string
+	Content         string
+	AttachmentName  string
+	// AttachmentHash is the Git blob ID of the attachment, which also names
+	// the stored copy kept for this revision.
+	AttachmentHash string
+	// Commit is the verbatim commit object of a revision that was pushed
+	// over Git, kept so that its commit ID never changes.
+	Commit    string
+	CreatedAt time.Time
+}
+
+// RevisionObject returns the name of the stored copy of the attachment
+// with the given blob ID.
+func RevisionObject(hash string) string {
+	return RevisionObjectPrefix + hash
+}
+
+// ToMap converts the revision to a map for Firestore storage.
+func (r *Revision) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"number":          int64(r.Number),
+		"userId":          r.UserID,
+		"message":         r.Message,
+		"contentFileName": r.ContentFileName,
+		"content":         r.Content,
+		"createdAt":       r.CreatedAt,
+	}
+
+	if r.AttachmentName != "" {
+		m["attachmentName"] = r.AttachmentName
+		m["attachmentHash"] = r.AttachmentHash
+	}
+	if r.Commit != "" {
+		m["commit"] = r.Commit
+	}
+
+	return m
+}
+
+// RevisionFromMap creates a Revision from Firestore document data.
+func RevisionFromMap(id, gistID string, data map[string]interface{}) *Revision {
+	r := &Revision{ID: id, GistID: gistID}
+
+	if v, ok := data["number"].(int64); ok {
+		r.Number = int(v)
+	}
+	if v, ok := data["userId"].(string); ok {
+		r.UserID = v
+	}
+	if v, ok := data["message"].(string); ok {
+		r.Message = v
+	}
+	if v, ok := data["contentFileName"].(string); ok {
+		r.ContentFileName = v
+	}
+	if v, ok := data["content"].(string); ok {
+		r.Content = v
+	}
+	if v, ok := data["attachmentName"].(string); ok {
+		r.AttachmentName = v
+	}
+	if v, ok := data["attachmentHash"].(string); ok {
+		r.AttachmentHash = v
+	}
+	if v, ok := data["commit"].(string); ok {
+		r.Commit = v
+	}
+	if v, ok := data["createdAt"].(time.Time); ok {
+		r.CreatedAt = v
+	}
+
+	return r
+}
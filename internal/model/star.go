@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Star records that a user bookmarked a gist.
+type Star struct {
+	UserID    string
+	GistID    string
+	CreatedAt time.Time
+}
+
+// NewStar creates a new Star with the current timestamp.
+func NewStar(userID, gistID string) *Star {
+	return &Star{
+		UserID:    userID,
+		GistID:    gistID,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// ToMap converts the star to a map for Firestore storage.
+func (s *Star) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"userId":    s.UserID,
+		"gistId":    s.GistID,
+		"createdAt": s.CreatedAt,
+	}
+}
+
+// StarFromMap creates a Star from Firestore document data.
+func StarFromMap(data map[string]interface{}) *Star {
+	s := &Star{}
+
+	if v, ok := data["userId"].(string); ok {
+		s.UserID = v
+	}
+	if v, ok := data["gistId"].(string); ok {
+		s.GistID = v
+	}
+	if v, ok := data["createdAt"].(time.Time); ok {
+		s.CreatedAt = v
+	}
+
+	return s
+}
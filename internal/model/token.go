@@ -0,0 +1,77 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// TokenPrefix marks API token secrets so that they are easy to spot when
+// leaked.
+const TokenPrefix = "qgt_"
+
+// APIToken is a personal access token. Only a hash of the secret is
+// stored; the secret itself is shown once when the token is created.
+type APIToken struct {
+	ID        string
+	UserID    string
+	Name      string
+	Hash      string
+	CreatedAt time.Time
+}
+
+// NewAPIToken creates a token for the given secret.
+func NewAPIToken(userID, name, secret string) *APIToken {
+	return &APIToken{
+		UserID:    userID,
+		Name:      name,
+		Hash:      HashToken(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// NewTokenSecret generates a random API token secret.
+func NewTokenSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return TokenPrefix + hex.EncodeToString(b), nil
+}
+
+// HashToken returns the stored form of a token secret.
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ToMap converts the token to a map for Firestore storage.
+func (t *APIToken) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"userId":    t.UserID,
+		"name":      t.Name,
+		"hash":      t.Hash,
+		"createdAt": t.CreatedAt,
+	}
+}
+
+// APITokenFromMap creates an APIToken from Firestore document data.
+func APITokenFromMap(id string, data map[string]interface{}) *APIToken {
+	t := &APIToken{ID: id}
+
+	if v, ok := data["userId"].(string); ok {
+		t.UserID = v
+	}
+	if v, ok := data["name"].(string); ok {
+		t.Name = v
+	}
+	if v, ok := data["hash"].(string); ok {
+		t.Hash = v
+	}
+	if v, ok := data["createdAt"].(time.Time); ok {
+		t.CreatedAt = v
+	}
+
+	return t
+}
@@ -0,0 +1,220 @@
+package model
+
+import "time"
+
+// WebhookEvent identifies a gist lifecycle event delivered to webhooks.
+type WebhookEvent string
+
+const (
+	// EventGistCreated fires when a gist is created.
+	EventGistCreated WebhookEvent = "gist.created"
+	// EventGistUpdated fires when a gist is edited.
+	EventGistUpdated WebhookEvent = "gist.updated"
+	// EventGistDeleted fires when a gist is deleted.
+	EventGistDeleted WebhookEvent = "gist.deleted"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to.
+var WebhookEvents = []WebhookEvent{EventGistCreated, EventGistUpdated, EventGistDeleted}
+
+// ParseWebhookEvent converts a string into a WebhookEvent, reporting false
+// for unknown events.
+func ParseWebhookEvent(s string) (WebhookEvent, bool) {
+	for _, e := range WebhookEvents {
+		if string(e) == s {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// DeliveryStatus tracks the progress of a webhook delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded deliveries were acknowledged with a 2xx response.
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed deliveries ran out of attempts.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Webhook is an endpoint notified about lifecycle events of its owner's
+// gists.
+type Webhook struct {
+	ID        string
+	UserID    string
+	URL       string
+	Secret    string
+	Events    []WebhookEvent
+	CreatedAt time.Time
+}
+
+// NewWebhook creates a new Webhook with the current timestamp.
+func NewWebhook(userID, url, secret string, events []WebhookEvent) *Webhook {
+	return &Webhook{
+		UserID:    userID,
+		URL:       url,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Subscribes reports whether the webhook wants the given event.
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ToMap converts the webhook to a map for Firestore storage.
+func (w *Webhook) ToMap() map[string]interface{} {
+	events := make([]string, len(w.Events))
+	for i, e := range w.Events {
+		events[i] = string(e)
+	}
+
+	return map[string]interface{}{
+		"userId":    w.UserID,
+		"url":       w.URL,
+		"secret":    w.Secret,
+		"events":    events,
+		"createdAt": w.CreatedAt,
+	}
+}
+
+// WebhookFromMap creates a Webhook from Firestore document data.
+func WebhookFromMap(id string, data map[string]interface{}) *Webhook {
+	w := &Webhook{ID: id}
+
+	if v, ok := data["userId"].(string); ok {
+		w.UserID = v
+	}
+	if v, ok := data["url"].(string); ok {
+		w.URL = v
+	}
+	if v, ok := data["secret"].(string); ok {
+		w.Secret = v
+	}
+	if v, ok := data["events"].([]interface{}); ok {
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				if event, ok := ParseWebhookEvent(s); ok {
+					w.Events = append(w.Events, event)
+				}
+			}
+		}
+	}
+	if v, ok := data["createdAt"].(time.Time); ok {
+		w.CreatedAt = v
+	}
+
+	return w
+}
+
+// WebhookDelivery is a queued or completed delivery of an event payload to
+// a webhook, along with the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID             string
+	WebhookID      string
+	UserID         string
+	Event          WebhookEvent
+	Payload        string
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastAttemptAt  time.Time
+	ResponseStatus int
+	Error          string
+	RedeliveryOf   string
+	CreatedAt      time.Time
+}
+
+// NewWebhookDelivery creates a pending delivery that is due immediately.
+func NewWebhookDelivery(webhook *Webhook, event WebhookEvent, payload string) *WebhookDelivery {
+	now := time.Now().UTC()
+	return &WebhookDelivery{
+		WebhookID:     webhook.ID,
+		UserID:        webhook.UserID,
+		Event:         event,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// ToMap converts the delivery to a map for Firestore storage.
+func (d *WebhookDelivery) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"webhookId":      d.WebhookID,
+		"userId":         d.UserID,
+		"event":          string(d.Event),
+		"payload":        d.Payload,
+		"status":         string(d.Status),
+		"attempts":       int64(d.Attempts),
+		"nextAttemptAt":  d.NextAttemptAt,
+		"responseStatus": int64(d.ResponseStatus),
+		"error":          d.Error,
+		"createdAt":      d.CreatedAt,
+	}
+
+	if !d.LastAttemptAt.IsZero() {
+		m["lastAttemptAt"] = d.LastAttemptAt
+	}
+	if d.RedeliveryOf != "" {
+		m["redeliveryOf"] = d.RedeliveryOf
+	}
+
+	return m
+}
+
+// WebhookDeliveryFromMap creates a WebhookDelivery from Firestore document
+// data.
+func WebhookDeliveryFromMap(id string, data map[string]interface{}) *WebhookDelivery {
+	d := &WebhookDelivery{ID: id}
+
+	if v, ok := data["webhookId"].(string); ok {
+		d.WebhookID = v
+	}
+	if v, ok := data["userId"].(string); ok {
+		d.UserID = v
+	}
+	if v, ok := data["event"].(string); ok {
+		d.Event = WebhookEvent(v)
+	}
+	if v, ok := data["payload"].(string); ok {
+		d.Payload = v
+	}
+	if v, ok := data["status"].(string); ok {
+		d.Status = DeliveryStatus(v)
+	}
+	if v, ok := data["attempts"].(int64); ok {
+		d.Attempts = int(v)
+	}
+	if v, ok := data["nextAttemptAt"].(time.Time); ok {
+		d.NextAttemptAt = v
+	}
+	if v, ok := data["lastAttemptAt"].(time.Time); ok {
+		d.LastAttemptAt = v
+	}
+	if v, ok := data["responseStatus"].(int64); ok {
+		d.ResponseStatus = int(v)
+	}
+	if v, ok := data["error"].(string); ok {
+		d.Error = v
+	}
+	if v, ok := data["redeliveryOf"].(string); ok {
+		d.RedeliveryOf = v
+	}
+	if v, ok := data["createdAt"].(time.Time); ok {
+		d.CreatedAt = v
+	}
+
+	return d
+}
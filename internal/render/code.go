@@ -0,0 +1,103 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// EmbedClassPrefix namespaces every class emitted for embedded gists so
+// they cannot collide with the styles of the host page.
+const EmbedClassPrefix = "qg-"
+
+const embedStyle = "github"
+
+var (
+	embedFormatter = chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.ClassPrefix(EmbedClassPrefix),
+		chromahtml.WithLineNumbers(true),
+		chromahtml.LineNumbersInTable(true),
+	)
+
+	embedStylesheet = buildEmbedStylesheet()
+)
+
+// Code highlights source in the given language for embedding, falling back
+// to content analysis and then plain text when the language is unknown.
+func Code(source, lang string) (string, error) {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Analyse(source)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := embedFormatter.Format(&buf, styles.Get(embedStyle), iterator); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// EmbedStylesheet returns the CSS used by embedded gists.
+func EmbedStylesheet() string {
+	return embedStylesheet
+}
+
+func buildEmbedStylesheet() string {
+	var buf strings.Builder
+	buf.WriteString(embedBaseCSS)
+	// Writing to a strings.Builder cannot fail.
+	_ = embedFormatter.WriteCSS(&buf, styles.Get(embedStyle))
+	return buf.String()
+}
+
+const embedBaseCSS = `.qg-embed-page {
+  margin: 0;
+}
+.qg-embed {
+  margin: 0 0 16px;
+  font: 12px/1.45 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace;
+  color: #1f2328;
+  text-align: left;
+}
+.qg-embed-file {
+  border: 1px solid #d0d7de;
+  border-radius: 6px;
+  overflow: hidden;
+  background: #fff;
+}
+.qg-embed-body {
+  overflow: auto;
+  max-height: 600px;
+}
+.qg-embed-body pre {
+  margin: 0;
+  padding: 8px 12px;
+}
+.qg-embed-meta {
+  padding: 8px 12px;
+  border-top: 1px solid #d0d7de;
+  background: #f6f8fa;
+  font: 11px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif;
+  color: #57606a;
+}
+.qg-embed-meta a {
+  color: #0969da;
+  text-decoration: none;
+  font-weight: 600;
+}
+`
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"bytes"
+	"regexp"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	markdown = goldmark.New(
+		goldmark.WithExtensions(
+			extension.NewTable(
+				extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute),
+			),
+			extension.Strikethrough,
+			extension.Linkify,
+			extension.TaskList,
+			highlighting.NewHighlighting(
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+	)
+
+	policy = newPolicy()
+
+	classPattern = regexp.MustCompile(`^[a-zA-Z0-9 _-]+$`)
+	alignPattern = regexp.MustCompile(`^(left|right|center)$`)
+)
+
+// Markdown converts CommonMark with GitHub Flavored Markdown tables, task
+// lists and highlighted fenced code into HTML that is safe to embed.
+func Markdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
+
+// newPolicy builds the allowlist applied to rendered output. Highlighting
+// relies on class names rather than inline styles so that the rendered HTML
+// still satisfies a strict Content-Security-Policy.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	p.AllowAttrs("class").Matching(classPattern).OnElements("pre", "code", "span")
+	p.AllowAttrs("align").Matching(alignPattern).OnElements("th", "td")
+
+	p.AllowElements("input")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
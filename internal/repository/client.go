@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	firebase "firebase.google.com/go"
+	"google.golang.org/api/option"
+
+	"github.com/abhisheksharm-3/quickgist/internal/config"
+)
+
+// NewFirestoreClient connects to Firestore, retrying up to cfg.MaxRetries
+// times with a growing delay.
+func NewFirestoreClient(ctx context.Context, cfg config.FirebaseConfig) (*firestore.Client, error) {
+	opt := option.WithCredentialsFile(cfg.CredentialsPath)
+	fbConfig := &firebase.Config{ProjectID: cfg.ProjectID}
+
+	app, err := firebase.NewApp(ctx, fbConfig, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var client *firestore.Client
+	for i := 0; i < cfg.MaxRetries; i++ {
+		client, err = app.Firestore(ctx)
+		if err == nil {
+			return client, nil
+		}
+		if i < cfg.MaxRetries-1 {
+			time.Sleep(time.Second * time.Duration(i+1))
+		}
+	}
+
+	return nil, err
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+)
+
+// cursor identifies the last document of a page. It is handed to clients as an
+// opaque base64 string.
+type cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+func encodeCursor(createdAt time.Time, id string) string {
+	data, _ := json.Marshal(cursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, apperror.BadRequest("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return c, apperror.BadRequest("invalid cursor")
+	}
+
+	return c, nil
+}
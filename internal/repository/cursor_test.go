@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		id        string
+	}{
+		{"utc", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), "abc123"},
+		{"nanoseconds", time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC), "abc123"},
+		{"other location", time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)), "abc123"},
+		{"zero time", time.Time{}, "abc123"},
+		{"id with separators", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "user_1/gist-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := decodeCursor(encodeCursor(tt.createdAt, tt.id))
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if !c.CreatedAt.Equal(tt.createdAt) {
+				t.Errorf("CreatedAt = %v, want %v", c.CreatedAt, tt.createdAt)
+			}
+			if c.ID != tt.id {
+				t.Errorf("ID = %q, want %q", c.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"not json", encode("abc")},
+		{"missing id", encode(`{"c":"2024-03-01T00:00:00Z"}`)},
+		{"empty id", encode(`{"c":"2024-03-01T00:00:00Z","i":""}`)},
+		{"invalid time", encode(`{"c":"yesterday","i":"a"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.cursor)
+			if !apperror.Is(err, apperror.CodeBadRequest) {
+				t.Errorf("decodeCursor(%q) error = %v, want a bad request", tt.cursor, err)
+			}
+		})
+	}
+}
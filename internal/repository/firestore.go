@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -19,6 +21,9 @@ const (
 	operationTimeout   = 5 * time.Second
 )
 
+// errGistModified aborts a conditional update of a gist that changed.
+var errGistModified = errors.New("gist modified")
+
 // FirestoreRepository implements GistRepository using Firestore.
 type FirestoreRepository struct {
 	client *firestore.Client
@@ -45,17 +50,30 @@ func (r *FirestoreRepository) Get(ctx context.Context, id string) (*model.Gist,
 	return model.GistFromMap(doc.Ref.ID, doc.Data()), nil
 }
 
-// Create saves a new gist and returns its ID.
+// NewID returns a new random gist ID.
+func (r *FirestoreRepository) NewID() string {
+	return r.client.Collection(collectionName).NewDoc().ID
+}
+
+// Create saves a new gist and returns its ID. A gist that already has an
+// ID is saved under it unless a gist with that ID exists.
 func (r *FirestoreRepository) Create(ctx context.Context, gist *model.Gist) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	docRef := r.client.Collection(collectionName).NewDoc()
-	if _, err := docRef.Set(ctx, gist.ToMap()); err != nil {
+	id := gist.ID
+	if id == "" {
+		id = r.NewID()
+	}
+
+	if _, err := r.client.Collection(collectionName).Doc(id).Create(ctx, gist.ToMap()); err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return "", apperror.Conflict("a gist with this ID already exists")
+		}
 		return "", apperror.Database(err)
 	}
 
-	return docRef.ID, nil
+	return id, nil
 }
 
 // Update updates an existing gist.
@@ -71,33 +89,244 @@ func (r *FirestoreRepository) Update(ctx context.Context, gist *model.Gist) erro
 	return nil
 }
 
-// ListByUser retrieves gists for a user with pagination.
-func (r *FirestoreRepository) ListByUser(ctx context.Context, userID string, limit int) ([]*model.Gist, error) {
+// UpdateIfUnchanged updates a gist in a transaction that first checks that
+// nobody else updated it since it was read.
+func (r *FirestoreRepository) UpdateIfUnchanged(ctx context.Context, gist *model.Gist, updatedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docRef := r.client.Collection(collectionName).Doc(gist.ID)
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var stored time.Time
+		if v, ok := doc.Data()["updatedAt"].(time.Time); ok {
+			stored = v
+		}
+		if !stored.Equal(updatedAt) {
+			return errGistModified
+		}
+
+		return tx.Set(docRef, gist.ToMap(), firestore.MergeAll)
+	})
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errGistModified):
+		return apperror.PreconditionFailed("the gist was modified; fetch it and try again")
+	case status.Code(err) == codes.NotFound:
+		return apperror.NotFound("gist")
+	default:
+		return apperror.Database(err)
+	}
+}
+
+// Delete removes a gist along with its comments, revisions, stars and star
+// counters.
+func (r *FirestoreRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docRef := r.client.Collection(collectionName).Doc(id)
+	writer := r.client.BulkWriter(ctx)
+
+	var jobs []*firestore.BulkWriterJob
+	deleteAll := func(refs []*firestore.DocumentRef) error {
+		for _, ref := range refs {
+			job, err := writer.Delete(ref)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+		return nil
+	}
+
+	for _, sub := range []string{commentsCollection, revisionsCollection, starShardsCollection} {
+		refs, err := docRef.Collection(sub).DocumentRefs(ctx).GetAll()
+		if err == nil {
+			err = deleteAll(refs)
+		}
+		if err != nil {
+			writer.End()
+			return apperror.Database(err)
+		}
+	}
+
+	// Stars live outside the gist so that a user's stars can be listed, and
+	// would otherwise outlive it in those listings.
+	stars, err := r.client.Collection(starsCollection).
+		Where("gistId", "==", id).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		writer.End()
+		return apperror.Database(err)
+	}
+	starRefs := make([]*firestore.DocumentRef, len(stars))
+	for i, doc := range stars {
+		starRefs[i] = doc.Ref
+	}
+	if err := deleteAll(starRefs); err != nil {
+		writer.End()
+		return apperror.Database(err)
+	}
+
+	job, err := writer.Delete(docRef)
+	if err != nil {
+		writer.End()
+		return apperror.Database(err)
+	}
+	jobs = append(jobs, job)
+
+	writer.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return apperror.Database(err)
+		}
+	}
+
+	return nil
+}
+
+// ListByUser retrieves a page of gists for a user along with the cursor for
+// the next page, which is empty on the last page.
+func (r *FirestoreRepository) ListByUser(ctx context.Context, userID string, opts ListOptions) ([]*model.Gist, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
+	query := r.client.Collection(collectionName).Where("userId", "==", userID)
+	if opts.Language != "" {
+		query = query.Where("language", "==", opts.Language)
+	}
+	if opts.Tag != "" {
+		query = query.Where("tags", "array-contains", opts.Tag)
+	}
+	if opts.IsDraft != nil {
+		query = query.Where("isDraft", "==", *opts.IsDraft)
+	}
+	if opts.Visibility != "" {
+		query = query.Where("visibility", "==", string(opts.Visibility))
+	}
+	if !opts.From.IsZero() {
+		query = query.Where("createdAt", ">=", opts.From)
+	}
+	if !opts.To.IsZero() {
+		query = query.Where("createdAt", "<", opts.To)
+	}
+
+	if len(opts.Fields) > 0 {
+		// createdAt is always loaded because the next cursor is built from it.
+		fields := opts.Fields[:len(opts.Fields):len(opts.Fields)]
+		if !slices.Contains(fields, "createdAt") {
+			fields = append(fields, "createdAt")
+		}
+		query = query.Select(fields...)
+	}
+
+	docs, next, err := page(ctx, query, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	gists := make([]*model.Gist, len(docs))
+	for i, doc := range docs {
+		gists[i] = model.GistFromMap(doc.Ref.ID, doc.Data())
+	}
+
+	return gists, next, nil
+}
+
+// page orders query by creation time and returns the documents of the page
+// selected by opts along with the cursor for the next page.
+func page(ctx context.Context, query firestore.Query, opts ListOptions) ([]*firestore.DocumentSnapshot, string, error) {
+	limit := opts.Limit
 	if limit <= 0 || limit > defaultQueryLimit {
 		limit = defaultQueryLimit
 	}
 
+	direction := firestore.Desc
+	if opts.Ascending {
+		direction = firestore.Asc
+	}
+
+	query = query.
+		OrderBy("createdAt", direction).
+		OrderBy(firestore.DocumentID, direction)
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.StartAfter(c.CreatedAt, c.ID)
+	}
+
+	docs, err := query.Limit(limit + 1).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, "", apperror.Database(err)
+	}
+
+	var next string
+	if len(docs) > limit {
+		docs = docs[:limit]
+		last := docs[limit-1]
+		createdAt, _ := last.Data()["createdAt"].(time.Time)
+		next = encodeCursor(createdAt, last.Ref.ID)
+	}
+
+	return docs, next, nil
+}
+
+// TagCounts returns how many of a user's gists carry each tag.
+func (r *FirestoreRepository) TagCounts(ctx context.Context, userID string) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
 	iter := r.client.Collection(collectionName).
 		Where("userId", "==", userID).
-		OrderBy("createdAt", firestore.Desc).
-		Limit(limit).
+		Select("tags").
 		Documents(ctx)
+	defer iter.Stop()
 
-	var gists []*model.Gist
+	counts := make(map[string]int)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
-			break
+			return counts, nil
 		}
 		if err != nil {
 			return nil, apperror.Database(err)
 		}
 
-		gists = append(gists, model.GistFromMap(doc.Ref.ID, doc.Data()))
+		for _, tag := range model.GistFromMap(doc.Ref.ID, doc.Data()).Tags {
+			counts[tag]++
+		}
 	}
+}
+
+// Walk calls fn for every stored gist, stopping at the first error.
+func (r *FirestoreRepository) Walk(ctx context.Context, fn func(*model.Gist) error) error {
+	iter := r.client.Collection(collectionName).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return apperror.Database(err)
+		}
 
-	return gists, nil
+		if err := fn(model.GistFromMap(doc.Ref.ID, doc.Data())); err != nil {
+			return err
+		}
+	}
 }
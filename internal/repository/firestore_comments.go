@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	commentsCollection = "comments"
+	maxComments        = 1000
+)
+
+// CreateComment saves a new comment and returns its ID.
+func (r *FirestoreRepository) CreateComment(ctx context.Context, comment *model.Comment) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docRef := r.comments(comment.GistID).NewDoc()
+	if _, err := docRef.Set(ctx, comment.ToMap()); err != nil {
+		return "", apperror.Database(err)
+	}
+
+	return docRef.ID, nil
+}
+
+// GetComment retrieves a comment of a gist by ID.
+func (r *FirestoreRepository) GetComment(ctx context.Context, gistID, id string) (*model.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	doc, err := r.comments(gistID).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, apperror.NotFound("comment")
+		}
+		return nil, apperror.Database(err)
+	}
+
+	return model.CommentFromMap(doc.Ref.ID, doc.Data()), nil
+}
+
+// UpdateComment updates an existing comment.
+func (r *FirestoreRepository) UpdateComment(ctx context.Context, comment *model.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	_, err := r.comments(comment.GistID).Doc(comment.ID).Set(ctx, comment.ToMap())
+	if err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// DeleteComment removes a comment.
+func (r *FirestoreRepository) DeleteComment(ctx context.Context, gistID, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	if _, err := r.comments(gistID).Doc(id).Delete(ctx); err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// ListComments retrieves the comments of a gist, oldest first.
+func (r *FirestoreRepository) ListComments(ctx context.Context, gistID string) ([]*model.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.comments(gistID).
+		OrderBy("createdAt", firestore.Asc).
+		Limit(maxComments).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	comments := make([]*model.Comment, len(docs))
+	for i, doc := range docs {
+		comments[i] = model.CommentFromMap(doc.Ref.ID, doc.Data())
+	}
+
+	return comments, nil
+}
+
+// ListUserComments retrieves the comments a user wrote on any gist, oldest
+// first.
+func (r *FirestoreRepository) ListUserComments(ctx context.Context, userID string) ([]*model.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.client.CollectionGroup(commentsCollection).
+		Where("userId", "==", userID).
+		OrderBy("createdAt", firestore.Asc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	comments := make([]*model.Comment, len(docs))
+	for i, doc := range docs {
+		comments[i] = model.CommentFromMap(doc.Ref.ID, doc.Data())
+	}
+
+	return comments, nil
+}
+
+func (r *FirestoreRepository) comments(gistID string) *firestore.CollectionRef {
+	return r.client.Collection(collectionName).Doc(gistID).Collection(commentsCollection)
+}
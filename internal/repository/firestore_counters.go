@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+// IncrementCounts adds batched counter increments to their gists. Gists
+// that no longer exist are skipped.
+func (r *FirestoreRepository) IncrementCounts(ctx context.Context, deltas map[string]model.GistCounts) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	writer := r.client.BulkWriter(ctx)
+
+	jobs := make([]*firestore.BulkWriterJob, 0, len(deltas))
+	for id, delta := range deltas {
+		var updates []firestore.Update
+		if delta.Views != 0 {
+			updates = append(updates, firestore.Update{Path: "viewCount", Value: firestore.Increment(delta.Views)})
+		}
+		if delta.Downloads != 0 {
+			updates = append(updates, firestore.Update{Path: "downloadCount", Value: firestore.Increment(delta.Downloads)})
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		job, err := writer.Update(r.client.Collection(collectionName).Doc(id), updates)
+		if err != nil {
+			writer.End()
+			return apperror.Database(err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	writer.End()
+
+	var errs []error
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil && status.Code(err) != codes.NotFound {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return apperror.Database(errors.Join(errs...))
+	}
+
+	return nil
+}
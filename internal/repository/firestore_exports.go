@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const exportsCollection = "exports"
+
+// CreateExport saves a new export and returns its ID.
+func (r *FirestoreRepository) CreateExport(ctx context.Context, export *model.Export) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docRef := r.client.Collection(exportsCollection).NewDoc()
+	if _, err := docRef.Set(ctx, export.ToMap()); err != nil {
+		return "", apperror.Database(err)
+	}
+
+	return docRef.ID, nil
+}
+
+// GetExport retrieves an export by ID.
+func (r *FirestoreRepository) GetExport(ctx context.Context, id string) (*model.Export, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	doc, err := r.client.Collection(exportsCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, apperror.NotFound("export")
+		}
+		return nil, apperror.Database(err)
+	}
+
+	return model.ExportFromMap(doc.Ref.ID, doc.Data()), nil
+}
+
+// UpdateExport updates an existing export.
+func (r *FirestoreRepository) UpdateExport(ctx context.Context, export *model.Export) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	_, err := r.client.Collection(exportsCollection).Doc(export.ID).Set(ctx, export.ToMap())
+	if err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// ListExports retrieves the exports of a user, most recent first.
+func (r *FirestoreRepository) ListExports(ctx context.Context, userID string) ([]*model.Export, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(exportsCollection).
+		Where("userId", "==", userID).
+		OrderBy("createdAt", firestore.Desc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	exports := make([]*model.Export, len(docs))
+	for i, doc := range docs {
+		exports[i] = model.ExportFromMap(doc.Ref.ID, doc.Data())
+	}
+
+	return exports, nil
+}
+
+// DeleteExport removes an export. Its archive is left to the caller.
+func (r *FirestoreRepository) DeleteExport(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	if _, err := r.client.Collection(exportsCollection).Doc(id).Delete(ctx); err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	// migrationsCollection holds a marker document for each data migration
+	// that has completed.
+	migrationsCollection = "migrations"
+	visibilityBackfillID = "visibility-backfill"
+
+	// backfillTimeout bounds a backfill, so that a stuck read does not keep
+	// it running indefinitely. An unfinished backfill resumes on the next
+	// start.
+	backfillTimeout = 30 * time.Minute
+)
+
+// WalkDocuments calls fn with the raw data of every stored gist, stopping
+// at the first error.
+func (r *FirestoreRepository) WalkDocuments(ctx context.Context, fn func(id string, data map[string]interface{}) error) error {
+	iter := r.client.Collection(collectionName).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return apperror.Database(err)
+		}
+
+		if err := fn(doc.Ref.ID, doc.Data()); err != nil {
+			return err
+		}
+	}
+}
+
+// SetFields overwrites the given fields of a stored gist without touching
+// the others. The gist must exist.
+func (r *FirestoreRepository) SetFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	updates := make([]firestore.Update, 0, len(fields))
+	for path, value := range fields {
+		updates = append(updates, firestore.Update{Path: path, Value: value})
+	}
+
+	if _, err := r.client.Collection(collectionName).Doc(id).Update(ctx, updates); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return apperror.NotFound("gist")
+		}
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// BackfillVisibility stores the public visibility on gists written before
+// visibility was stored. They read as public, but queries filtering on
+// visibility skip documents that lack the field. The backfill runs until it
+// completes once; later calls return straight away. It returns how many
+// gists it updated.
+func (r *FirestoreRepository) BackfillVisibility(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, backfillTimeout)
+	defer cancel()
+
+	marker := r.client.Collection(migrationsCollection).Doc(visibilityBackfillID)
+	if _, err := marker.Get(ctx); err == nil {
+		return 0, nil
+	} else if status.Code(err) != codes.NotFound {
+		return 0, apperror.Database(err)
+	}
+
+	iter := r.client.Collection(collectionName).Documents(ctx)
+	defer iter.Stop()
+
+	count := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return count, apperror.Database(err)
+		}
+		if v, _ := doc.Data()["visibility"].(string); v != "" {
+			continue
+		}
+
+		// A gist saved since it was read carries the visibility it was
+		// saved with, which must not be overwritten.
+		_, err = doc.Ref.Update(ctx,
+			[]firestore.Update{{Path: "visibility", Value: string(model.VisibilityPublic)}},
+			firestore.LastUpdateTime(doc.UpdateTime))
+		switch status.Code(err) {
+		case codes.OK:
+			count++
+		case codes.FailedPrecondition, codes.NotFound:
+		default:
+			return count, apperror.Database(err)
+		}
+	}
+
+	if _, err := marker.Set(ctx, map[string]interface{}{"completedAt": time.Now().UTC()}); err != nil {
+		return count, apperror.Database(err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+// ImportGist saves a gist under its own ID, replacing any stored gist with
+// that ID. Its revisions, comments and stars are kept.
+func (r *FirestoreRepository) ImportGist(ctx context.Context, gist *model.Gist) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	data := gist.ToMap()
+	data["viewCount"] = gist.ViewCount
+	data["downloadCount"] = gist.DownloadCount
+
+	if _, err := r.client.Collection(collectionName).Doc(gist.ID).Set(ctx, data); err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// ImportComment saves a comment under its own ID, replacing any stored
+// comment with that ID.
+func (r *FirestoreRepository) ImportComment(ctx context.Context, comment *model.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	if _, err := r.comments(comment.GistID).Doc(comment.ID).Set(ctx, comment.ToMap()); err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// ImportStar saves a star with its timestamp, counting it unless it was
+// already imported.
+func (r *FirestoreRepository) ImportStar(ctx context.Context, star *model.Star) error {
+	_, err := r.addStar(ctx, star)
+	return err
+}
+
+// ImportToken saves an API token under its own ID, replacing any stored
+// token with that ID. Only the hash of its secret is copied, which keeps
+// the secret working.
+func (r *FirestoreRepository) ImportToken(ctx context.Context, token *model.APIToken) error {
+	return r.importDocument(ctx, r.client.Collection(tokensCollection).Doc(token.ID), token.ToMap())
+}
+
+// ImportWebhook saves a webhook under its own ID, replacing any stored
+// webhook with that ID.
+func (r *FirestoreRepository) ImportWebhook(ctx context.Context, webhook *model.Webhook) error {
+	return r.importDocument(ctx, r.client.Collection(webhooksCollection).Doc(webhook.ID), webhook.ToMap())
+}
+
+// ImportDelivery saves a webhook delivery under its own ID, replacing any
+// stored delivery with that ID.
+func (r *FirestoreRepository) ImportDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.importDocument(ctx, r.client.Collection(deliveriesCollection).Doc(delivery.ID), delivery.ToMap())
+}
+
+// ImportExport saves an export under its own ID, replacing any stored
+// export with that ID.
+func (r *FirestoreRepository) ImportExport(ctx context.Context, export *model.Export) error {
+	return r.importDocument(ctx, r.client.Collection(exportsCollection).Doc(export.ID), export.ToMap())
+}
+
+func (r *FirestoreRepository) importDocument(ctx context.Context, ref *firestore.DocumentRef, data map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	if _, err := ref.Set(ctx, data); err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// WalkComments calls fn for every stored comment of any gist, stopping at
+// the first error.
+func (r *FirestoreRepository) WalkComments(ctx context.Context, fn func(*model.Comment) error) error {
+	return walkDocuments(ctx, r.client.CollectionGroup(commentsCollection).Query, func(doc *firestore.DocumentSnapshot) error {
+		return fn(model.CommentFromMap(doc.Ref.ID, doc.Data()))
+	})
+}
+
+// WalkStars calls fn for every stored star, stopping at the first error.
+func (r *FirestoreRepository) WalkStars(ctx context.Context, fn func(*model.Star) error) error {
+	return walkDocuments(ctx, r.client.Collection(starsCollection).Query, func(doc *firestore.DocumentSnapshot) error {
+		return fn(model.StarFromMap(doc.Data()))
+	})
+}
+
+// WalkTokens calls fn for every stored API token, stopping at the first
+// error.
+func (r *FirestoreRepository) WalkTokens(ctx context.Context, fn func(*model.APIToken) error) error {
+	return walkDocuments(ctx, r.client.Collection(tokensCollection).Query, func(doc *firestore.DocumentSnapshot) error {
+		return fn(model.APITokenFromMap(doc.Ref.ID, doc.Data()))
+	})
+}
+
+// WalkWebhooks calls fn for every stored webhook, stopping at the first
+// error.
+func (r *FirestoreRepository) WalkWebhooks(ctx context.Context, fn func(*model.Webhook) error) error {
+	return walkDocuments(ctx, r.client.Collection(webhooksCollection).Query, func(doc *firestore.DocumentSnapshot) error {
+		return fn(model.WebhookFromMap(doc.Ref.ID, doc.Data()))
+	})
+}
+
+// WalkDeliveries calls fn for every stored webhook delivery, stopping at
+// the first error.
+func (r *FirestoreRepository) WalkDeliveries(ctx context.Context, fn func(*model.WebhookDelivery) error) error {
+	return walkDocuments(ctx, r.client.Collection(deliveriesCollection).Query, func(doc *firestore.DocumentSnapshot) error {
+		return fn(model.WebhookDeliveryFromMap(doc.Ref.ID, doc.Data()))
+	})
+}
+
+// WalkExports calls fn for every stored export, stopping at the first
+// error.
+func (r *FirestoreRepository) WalkExports(ctx context.Context, fn func(*model.Export) error) error {
+	return walkDocuments(ctx, r.client.Collection(exportsCollection).Query, func(doc *firestore.DocumentSnapshot) error {
+		return fn(model.ExportFromMap(doc.Ref.ID, doc.Data()))
+	})
+}
+
+// walkDocuments calls fn for every document query returns, stopping at
+// the first error.
+func walkDocuments(ctx context.Context, query firestore.Query, fn func(*firestore.DocumentSnapshot) error) error {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return apperror.Database(err)
+		}
+
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+}
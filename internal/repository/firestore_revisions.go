@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const revisionsCollection = "revisions"
+
+// CreateRevisions saves revisions in a single transaction. Documents are
+// keyed by revision number, so a concurrent writer that recorded the same
+// numbers first makes the whole batch fail.
+func (r *FirestoreRepository) CreateRevisions(ctx context.Context, gistID string, revisions []*model.Revision) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		for _, rev := range revisions {
+			if err := tx.Create(r.revisions(gistID).Doc(revisionDocID(rev.Number)), rev.ToMap()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, firestore.MaxAttempts(1))
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return apperror.Conflict("gist history changed concurrently")
+		}
+		return apperror.Database(err)
+	}
+
+	for _, rev := range revisions {
+		rev.ID = revisionDocID(rev.Number)
+		rev.GistID = gistID
+	}
+
+	return nil
+}
+
+// ListRevisions retrieves every revision of a gist, oldest first.
+func (r *FirestoreRepository) ListRevisions(ctx context.Context, gistID string) ([]*model.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.revisions(gistID).
+		OrderBy("number", firestore.Asc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	revisions := make([]*model.Revision, len(docs))
+	for i, doc := range docs {
+		revisions[i] = model.RevisionFromMap(doc.Ref.ID, gistID, doc.Data())
+	}
+
+	return revisions, nil
+}
+
+func (r *FirestoreRepository) revisions(gistID string) *firestore.CollectionRef {
+	return r.client.Collection(collectionName).Doc(gistID).Collection(revisionsCollection)
+}
+
+// revisionDocID zero-pads revision numbers so that document IDs sort in
+// revision order.
+func revisionDocID(number int) string {
+	return fmt.Sprintf("%08d", number)
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	starsCollection      = "stars"
+	starShardsCollection = "starShards"
+
+	// starShards spreads star count updates of a gist over several documents
+	// so that popular gists stay below the per-document write rate.
+	starShards = 10
+)
+
+// Star records that a user starred a gist, reporting false if it was
+// already starred.
+func (r *FirestoreRepository) Star(ctx context.Context, userID, gistID string) (bool, error) {
+	return r.addStar(ctx, model.NewStar(userID, gistID))
+}
+
+// addStar saves a star and counts it, reporting false if the user already
+// starred the gist.
+func (r *FirestoreRepository) addStar(ctx context.Context, star *model.Star) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var created bool
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		created = false
+		starRef := r.starRef(star.UserID, star.GistID)
+
+		_, err := tx.Get(starRef)
+		if err == nil {
+			return nil
+		}
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if err := tx.Create(starRef, star.ToMap()); err != nil {
+			return err
+		}
+		created = true
+		return tx.Set(r.randomStarShard(star.GistID), map[string]interface{}{
+			"count": firestore.Increment(1),
+		}, firestore.MergeAll)
+	})
+	if err != nil {
+		return false, apperror.Database(err)
+	}
+
+	return created, nil
+}
+
+// Unstar removes a user's star from a gist, reporting false if it was not
+// starred.
+func (r *FirestoreRepository) Unstar(ctx context.Context, userID, gistID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var deleted bool
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		deleted = false
+		starRef := r.starRef(userID, gistID)
+
+		_, err := tx.Get(starRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(starRef); err != nil {
+			return err
+		}
+		deleted = true
+		return tx.Set(r.randomStarShard(gistID), map[string]interface{}{
+			"count": firestore.Increment(-1),
+		}, firestore.MergeAll)
+	})
+	if err != nil {
+		return false, apperror.Database(err)
+	}
+
+	return deleted, nil
+}
+
+// IsStarred reports whether a user has starred a gist.
+func (r *FirestoreRepository) IsStarred(ctx context.Context, userID, gistID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	_, err := r.starRef(userID, gistID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, apperror.Database(err)
+	}
+
+	return true, nil
+}
+
+// StarCount returns the number of stars of a gist by summing its counter
+// shards.
+func (r *FirestoreRepository) StarCount(ctx context.Context, gistID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(collectionName).Doc(gistID).
+		Collection(starShardsCollection).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return 0, apperror.Database(err)
+	}
+
+	var total int64
+	for _, doc := range docs {
+		if v, ok := doc.Data()["count"].(int64); ok {
+			total += v
+		}
+	}
+
+	return total, nil
+}
+
+// ListStars retrieves a page of a user's stars, most recent first unless
+// opts.Ascending is set.
+func (r *FirestoreRepository) ListStars(ctx context.Context, userID string, opts ListOptions) ([]*model.Star, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := r.client.Collection(starsCollection).Where("userId", "==", userID)
+
+	docs, next, err := page(ctx, query, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	stars := make([]*model.Star, len(docs))
+	for i, doc := range docs {
+		stars[i] = model.StarFromMap(doc.Data())
+	}
+
+	return stars, next, nil
+}
+
+func (r *FirestoreRepository) starRef(userID, gistID string) *firestore.DocumentRef {
+	return r.client.Collection(starsCollection).Doc(userID + "_" + gistID)
+}
+
+func (r *FirestoreRepository) randomStarShard(gistID string) *firestore.DocumentRef {
+	return r.client.Collection(collectionName).Doc(gistID).
+		Collection(starShardsCollection).
+		Doc(strconv.Itoa(rand.IntN(starShards)))
+}
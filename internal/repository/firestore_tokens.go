@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const tokensCollection = "apiTokens"
+
+// CreateToken saves a new API token and returns its ID.
+func (r *FirestoreRepository) CreateToken(ctx context.Context, token *model.APIToken) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docRef := r.client.Collection(tokensCollection).NewDoc()
+	if _, err := docRef.Set(ctx, token.ToMap()); err != nil {
+		return "", apperror.Database(err)
+	}
+
+	return docRef.ID, nil
+}
+
+// GetToken retrieves an API token by ID.
+func (r *FirestoreRepository) GetToken(ctx context.Context, id string) (*model.APIToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	doc, err := r.client.Collection(tokensCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, apperror.NotFound("token")
+		}
+		return nil, apperror.Database(err)
+	}
+
+	return model.APITokenFromMap(doc.Ref.ID, doc.Data()), nil
+}
+
+// FindToken retrieves the API token with the given secret hash.
+func (r *FirestoreRepository) FindToken(ctx context.Context, hash string) (*model.APIToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(tokensCollection).
+		Where("hash", "==", hash).
+		Limit(1).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+	if len(docs) == 0 {
+		return nil, apperror.NotFound("token")
+	}
+
+	return model.APITokenFromMap(docs[0].Ref.ID, docs[0].Data()), nil
+}
+
+// ListTokens retrieves the API tokens of a user.
+func (r *FirestoreRepository) ListTokens(ctx context.Context, userID string) ([]*model.APIToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(tokensCollection).
+		Where("userId", "==", userID).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	tokens := make([]*model.APIToken, len(docs))
+	for i, doc := range docs {
+		tokens[i] = model.APITokenFromMap(doc.Ref.ID, doc.Data())
+	}
+
+	return tokens, nil
+}
+
+// DeleteToken revokes an API token.
+func (r *FirestoreRepository) DeleteToken(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	if _, err := r.client.Collection(tokensCollection).Doc(id).Delete(ctx); err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	webhooksCollection   = "webhooks"
+	deliveriesCollection = "webhookDeliveries"
+)
+
+// CreateWebhook saves a new webhook and returns its ID.
+func (r *FirestoreRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docRef := r.client.Collection(webhooksCollection).NewDoc()
+	if _, err := docRef.Set(ctx, webhook.ToMap()); err != nil {
+		return "", apperror.Database(err)
+	}
+
+	return docRef.ID, nil
+}
+
+// GetWebhook retrieves a webhook by ID.
+func (r *FirestoreRepository) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	doc, err := r.client.Collection(webhooksCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, apperror.NotFound("webhook")
+		}
+		return nil, apperror.Database(err)
+	}
+
+	return model.WebhookFromMap(doc.Ref.ID, doc.Data()), nil
+}
+
+// ListWebhooks retrieves the webhooks registered by a user.
+func (r *FirestoreRepository) ListWebhooks(ctx context.Context, userID string) ([]*model.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.client.Collection(webhooksCollection).
+		Where("userId", "==", userID).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	webhooks := make([]*model.Webhook, len(docs))
+	for i, doc := range docs {
+		webhooks[i] = model.WebhookFromMap(doc.Ref.ID, doc.Data())
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook. Its delivery log is kept until
+// DeleteUserDeliveries removes it.
+func (r *FirestoreRepository) DeleteWebhook(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	if _, err := r.client.Collection(webhooksCollection).Doc(id).Delete(ctx); err != nil {
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// CreateDelivery queues a webhook delivery and returns its ID.
+func (r *FirestoreRepository) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docRef := r.client.Collection(deliveriesCollection).NewDoc()
+	if _, err := docRef.Set(ctx, delivery.ToMap()); err != nil {
+		return "", apperror.Database(err)
+	}
+
+	return docRef.ID, nil
+}
+
+// GetDelivery retrieves a webhook delivery by ID.
+func (r *FirestoreRepository) GetDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	doc, err := r.client.Collection(deliveriesCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, apperror.NotFound("delivery")
+		}
+		return nil, apperror.Database(err)
+	}
+
+	return model.WebhookDeliveryFromMap(doc.Ref.ID, doc.Data()), nil
+}
+
+// UpdateDelivery records the outcome of a delivery attempt. It fails
+// rather than recreating a delivery that was deleted meanwhile.
+func (r *FirestoreRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	fields := delivery.ToMap()
+	updates := make([]firestore.Update, 0, len(fields))
+	for path, value := range fields {
+		updates = append(updates, firestore.Update{Path: path, Value: value})
+	}
+
+	if _, err := r.client.Collection(deliveriesCollection).Doc(delivery.ID).Update(ctx, updates); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return apperror.NotFound("delivery")
+		}
+		return apperror.Database(err)
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves a page of a webhook's deliveries, most recent
+// first unless opts.Ascending is set.
+func (r *FirestoreRepository) ListDeliveries(ctx context.Context, webhookID string, opts ListOptions) ([]*model.WebhookDelivery, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := r.client.Collection(deliveriesCollection).Where("webhookId", "==", webhookID)
+
+	docs, next, err := page(ctx, query, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	deliveries := make([]*model.WebhookDelivery, len(docs))
+	for i, doc := range docs {
+		deliveries[i] = model.WebhookDeliveryFromMap(doc.Ref.ID, doc.Data())
+	}
+
+	return deliveries, next, nil
+}
+
+// CountUserDeliveries returns how many deliveries are logged for the
+// webhooks of a user, including webhooks that were deleted since.
+func (r *FirestoreRepository) CountUserDeliveries(ctx context.Context, userID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.userDeliveries(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(docs), nil
+}
+
+// DeleteUserDeliveries removes the deliveries logged for the webhooks of a
+// user, including webhooks that were deleted since, and returns how many
+// it removed.
+func (r *FirestoreRepository) DeleteUserDeliveries(ctx context.Context, userID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	docs, err := r.userDeliveries(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	writer := r.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	for _, doc := range docs {
+		job, err := writer.Delete(doc.Ref)
+		if err != nil {
+			writer.End()
+			return 0, apperror.Database(err)
+		}
+		jobs = append(jobs, job)
+	}
+	writer.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return 0, apperror.Database(err)
+		}
+	}
+
+	return len(docs), nil
+}
+
+// userDeliveries loads references to the deliveries of a user's webhooks,
+// without their fields.
+func (r *FirestoreRepository) userDeliveries(ctx context.Context, userID string) ([]*firestore.DocumentSnapshot, error) {
+	docs, err := r.client.Collection(deliveriesCollection).
+		Where("userId", "==", userID).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	return docs, nil
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries whose next
+// attempt is due.
+func (r *FirestoreRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := r.client.Collection(deliveriesCollection).
+		Where("status", "==", string(model.DeliveryPending)).
+		Where("nextAttemptAt", "<=", now).
+		OrderBy("nextAttemptAt", firestore.Asc).
+		Limit(limit)
+
+	var claimed []*model.WebhookDelivery
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = nil
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+
+		leaseUntil := now.Add(lease)
+		for _, doc := range docs {
+			if err := tx.Update(doc.Ref, []firestore.Update{{Path: "nextAttemptAt", Value: leaseUntil}}); err != nil {
+				return err
+			}
+			claimed = append(claimed, model.WebhookDeliveryFromMap(doc.Ref.ID, doc.Data()))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, apperror.Database(err)
+	}
+
+	return claimed, nil
+}
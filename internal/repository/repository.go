@@ -2,15 +2,157 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/abhisheksharm-3/quickgist/internal/model"
 )
 
+// Repository combines every data access interface used by the API.
+type Repository interface {
+	GistRepository
+	StarRepository
+	CommentRepository
+	CounterRepository
+	WebhookRepository
+	TokenRepository
+	RevisionRepository
+	ExportRepository
+}
+
 // GistRepository defines the interface for gist data access.
 type GistRepository interface {
 	Get(ctx context.Context, id string) (*model.Gist, error)
+	// NewID returns an unused ID without saving anything.
+	NewID() string
+	// Create saves a gist that already has an ID under it, failing with a
+	// conflict if the ID is taken.
 	Create(ctx context.Context, gist *model.Gist) (string, error)
 	Update(ctx context.Context, gist *model.Gist) error
-	ListByUser(ctx context.Context, userID string, limit int) ([]*model.Gist, error)
+	// UpdateIfUnchanged only writes when the stored gist was last updated
+	// at updatedAt and fails with a precondition error otherwise.
+	UpdateIfUnchanged(ctx context.Context, gist *model.Gist, updatedAt time.Time) error
+	Delete(ctx context.Context, id string) error
+	ListByUser(ctx context.Context, userID string, opts ListOptions) ([]*model.Gist, string, error)
+	Walk(ctx context.Context, fn func(*model.Gist) error) error
+	TagCounts(ctx context.Context, userID string) (map[string]int, error)
+}
+
+// StarRepository defines the interface for gist stars. Star and Unstar
+// report whether they changed anything so that repeated calls are no-ops.
+type StarRepository interface {
+	Star(ctx context.Context, userID, gistID string) (bool, error)
+	Unstar(ctx context.Context, userID, gistID string) (bool, error)
+	IsStarred(ctx context.Context, userID, gistID string) (bool, error)
+	StarCount(ctx context.Context, gistID string) (int64, error)
+	ListStars(ctx context.Context, userID string, opts ListOptions) ([]*model.Star, string, error)
+}
+
+// CommentRepository defines the interface for gist comments.
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment *model.Comment) (string, error)
+	GetComment(ctx context.Context, gistID, id string) (*model.Comment, error)
+	UpdateComment(ctx context.Context, comment *model.Comment) error
+	DeleteComment(ctx context.Context, gistID, id string) error
+	ListComments(ctx context.Context, gistID string) ([]*model.Comment, error)
+	ListUserComments(ctx context.Context, userID string) ([]*model.Comment, error)
 }
 
+// CounterRepository defines the interface for gist usage counters.
+type CounterRepository interface {
+	IncrementCounts(ctx context.Context, deltas map[string]model.GistCounts) error
+}
+
+// WebhookRepository defines the interface for webhooks and their delivery
+// queue. ClaimDueDeliveries leases due deliveries by pushing their next
+// attempt back, so that a crashed worker's deliveries are retried once the
+// lease expires.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) (string, error)
+	GetWebhook(ctx context.Context, id string) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context, userID string) ([]*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) (string, error)
+	GetDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID string, opts ListOptions) ([]*model.WebhookDelivery, string, error)
+	CountUserDeliveries(ctx context.Context, userID string) (int, error)
+	DeleteUserDeliveries(ctx context.Context, userID string) (int, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error)
+}
+
+// TokenRepository defines the interface for API tokens. FindToken looks a
+// token up by the hash of its secret.
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token *model.APIToken) (string, error)
+	GetToken(ctx context.Context, id string) (*model.APIToken, error)
+	FindToken(ctx context.Context, hash string) (*model.APIToken, error)
+	ListTokens(ctx context.Context, userID string) ([]*model.APIToken, error)
+	DeleteToken(ctx context.Context, id string) error
+}
+
+// RevisionRepository defines the interface for gist revisions.
+// CreateRevisions fails with a conflict error when a revision with the same
+// number already exists, so that concurrent writers cannot fork the
+// history.
+type RevisionRepository interface {
+	CreateRevisions(ctx context.Context, gistID string, revisions []*model.Revision) error
+	ListRevisions(ctx context.Context, gistID string) ([]*model.Revision, error)
+}
+
+// ExportRepository defines the interface for personal data exports.
+type ExportRepository interface {
+	CreateExport(ctx context.Context, export *model.Export) (string, error)
+	GetExport(ctx context.Context, id string) (*model.Export, error)
+	UpdateExport(ctx context.Context, export *model.Export) error
+	ListExports(ctx context.Context, userID string) ([]*model.Export, error)
+	DeleteExport(ctx context.Context, id string) error
+}
+
+// MaintenanceRepository gives maintenance tools raw access to stored gist
+// documents, so that fields written by older versions can be repaired.
+// It is not part of Repository since the API never needs it.
+type MaintenanceRepository interface {
+	WalkDocuments(ctx context.Context, fn func(id string, data map[string]interface{}) error) error
+	SetFields(ctx context.Context, id string, fields map[string]interface{}) error
+}
+
+// MigrationRepository copies records between backends. Imports keep the
+// IDs and timestamps of the records and replace any record with the same
+// ID, so that an interrupted migration can be rerun. ImportStar only
+// counts a star that was not imported before.
+type MigrationRepository interface {
+	ImportGist(ctx context.Context, gist *model.Gist) error
+	ImportComment(ctx context.Context, comment *model.Comment) error
+	ImportStar(ctx context.Context, star *model.Star) error
+	ImportToken(ctx context.Context, token *model.APIToken) error
+	ImportWebhook(ctx context.Context, webhook *model.Webhook) error
+	ImportDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	ImportExport(ctx context.Context, export *model.Export) error
+	WalkComments(ctx context.Context, fn func(*model.Comment) error) error
+	WalkStars(ctx context.Context, fn func(*model.Star) error) error
+	WalkTokens(ctx context.Context, fn func(*model.APIToken) error) error
+	WalkWebhooks(ctx context.Context, fn func(*model.Webhook) error) error
+	WalkDeliveries(ctx context.Context, fn func(*model.WebhookDelivery) error) error
+	WalkExports(ctx context.Context, fn func(*model.Export) error) error
+}
+
+// ListOptions controls pagination and filtering of list queries; filters
+// only apply to gist listings. Cursor is the
+// opaque value returned with the previous page; results are ordered by
+// creation time, newest first unless Ascending is set. Fields names the
+// document fields to load; all fields are loaded when it is empty.
+// Filters match stored fields only, so gists written before visibility
+// was stored match no Visibility filter until the server's startup
+// backfill fills it in.
+type ListOptions struct {
+	Limit      int
+	Cursor     string
+	Language   string
+	Tag        string
+	IsDraft    *bool
+	Visibility model.Visibility
+	From       time.Time
+	To         time.Time
+	Ascending  bool
+	Fields     []string
+}
@@ -0,0 +1,256 @@
+package search
+
+import (
+	"math"
+	"sort"
+
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 50
+)
+
+var fieldBoosts = map[string]float64{
+	FieldTitle:       3,
+	FieldDescription: 2,
+	FieldContent:     1,
+}
+
+// NewMemoryIndex creates a new in-memory search index.
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// Index adds a gist to the index, replacing any previous version.
+func (idx *MemoryIndex) Index(gist *model.Gist) error {
+	doc := &document{
+		id:         gist.ID,
+		userID:     gist.UserID,
+		language:   gist.ResolvedLanguage(),
+		visibility: gist.Visibility,
+		isDraft:    gist.IsDraft,
+		createdAt:  gist.CreatedAt,
+		positions:  make(map[string]map[string][]int),
+	}
+
+	fields := map[string]string{
+		FieldTitle:       gist.Title,
+		FieldDescription: gist.Description,
+		FieldContent:     gist.Content,
+	}
+	for field, text := range fields {
+		tokens := tokenize(text)
+		positions := make(map[string][]int)
+		for i, token := range tokens {
+			positions[token] = append(positions[token], i)
+		}
+		doc.positions[field] = positions
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(gist.ID)
+	idx.docs[gist.ID] = doc
+	for _, positions := range doc.positions {
+		for term := range positions {
+			ids, ok := idx.postings[term]
+			if !ok {
+				ids = make(map[string]struct{})
+				idx.postings[term] = ids
+			}
+			ids[gist.ID] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a gist from the index.
+func (idx *MemoryIndex) Delete(id string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	return nil
+}
+
+// Search returns the gists matching the query that the caller may see,
+// best matches first.
+func (idx *MemoryIndex) Search(query Query) ([]Hit, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var hits []Hit
+	for id := range idx.candidates(query.Clauses) {
+		doc := idx.docs[id]
+		if !doc.visibleTo(query.CallerID) || !doc.matchesFilters(query) {
+			continue
+		}
+
+		score, ok := idx.score(doc, query.Clauses)
+		if !ok {
+			continue
+		}
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		a, b := idx.docs[hits[i].ID], idx.docs[hits[j].ID]
+		if !a.createdAt.Equal(b.createdAt) {
+			return a.createdAt.After(b.createdAt)
+		}
+		return a.id < b.id
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+func (idx *MemoryIndex) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, positions := range doc.positions {
+		for term := range positions {
+			delete(idx.postings[term], id)
+			if len(idx.postings[term]) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// candidates narrows the search to documents containing the rarest query
+// term. Without clauses every document is a candidate.
+func (idx *MemoryIndex) candidates(clauses []Clause) map[string]struct{} {
+	var best map[string]struct{}
+	found := false
+
+	for _, clause := range clauses {
+		for _, term := range clause.Terms {
+			ids := idx.postings[term]
+			if !found || len(ids) < len(best) {
+				best, found = ids, true
+			}
+		}
+	}
+
+	if found {
+		return best
+	}
+
+	all := make(map[string]struct{}, len(idx.docs))
+	for id := range idx.docs {
+		all[id] = struct{}{}
+	}
+	return all
+}
+
+// score ranks a document against every clause, reporting false when any
+// clause does not match.
+func (idx *MemoryIndex) score(doc *document, clauses []Clause) (float64, bool) {
+	var total float64
+
+	for _, clause := range clauses {
+		fields := []string{FieldTitle, FieldDescription, FieldContent}
+		if clause.Field != "" {
+			fields = []string{clause.Field}
+		}
+
+		var clauseScore float64
+		for _, field := range fields {
+			count := countPhrase(doc.positions[field], clause.Terms)
+			if count == 0 {
+				continue
+			}
+			clauseScore += fieldBoosts[field] * (1 + math.Log(float64(count))) * idx.idf(clause.Terms)
+		}
+
+		if clauseScore == 0 {
+			return 0, false
+		}
+		total += clauseScore
+	}
+
+	return total, true
+}
+
+func (idx *MemoryIndex) idf(terms []string) float64 {
+	df := len(idx.docs)
+	for _, term := range terms {
+		if n := len(idx.postings[term]); n < df {
+			df = n
+		}
+	}
+	return math.Log(1 + float64(len(idx.docs))/float64(df+1))
+}
+
+// countPhrase counts the occurrences of terms at consecutive positions.
+func countPhrase(positions map[string][]int, terms []string) int {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, start := range positions[terms[0]] {
+		matched := true
+		for offset, term := range terms[1:] {
+			want := start + offset + 1
+			list := positions[term]
+			i := sort.SearchInts(list, want)
+			if i == len(list) || list[i] != want {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+		}
+	}
+	return count
+}
+
+// visibleTo reports whether a caller may see the document: their own gists
+// plus published public gists.
+func (d *document) visibleTo(callerID string) bool {
+	if callerID != "" && d.userID == callerID {
+		return true
+	}
+	return d.visibility == model.VisibilityPublic && !d.isDraft
+}
+
+func (d *document) matchesFilters(query Query) bool {
+	if query.Language != "" && d.language != query.Language {
+		return false
+	}
+	if !query.From.IsZero() && d.createdAt.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && !d.createdAt.Before(query.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ParseQuery splits user input into clauses. Quoted text is matched as a
+// phrase and a title:, description: or content: prefix restricts a clause
+// to that field.
+func ParseQuery(input string) []Clause {
+	var clauses []Clause
+
+	rest := strings.TrimSpace(input)
+	for rest != "" {
+		var field string
+		if name, after, ok := strings.Cut(rest, ":"); ok && isField(name) {
+			field, rest = strings.ToLower(name), after
+		}
+
+		var text string
+		if strings.HasPrefix(rest, `"`) {
+			text, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+		rest = strings.TrimSpace(rest)
+
+		if terms := tokenize(text); len(terms) > 0 {
+			clauses = append(clauses, Clause{Field: field, Terms: terms})
+		}
+	}
+
+	return clauses
+}
+
+func isField(name string) bool {
+	switch strings.ToLower(name) {
+	case FieldTitle, FieldDescription, FieldContent:
+		return true
+	}
+	return false
+}
+
+// tokenize lowercases text and splits it into letter and digit runs.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Clause
+	}{
+		{"empty", "", nil},
+		{"whitespace", "  \t ", nil},
+		{"punctuation only", "!!! ---", nil},
+		{
+			name:  "words",
+			input: "Hello  World",
+			want:  []Clause{{Terms: []string{"hello"}}, {Terms: []string{"world"}}},
+		},
+		{
+			name:  "word split on punctuation",
+			input: "http.Handler",
+			want:  []Clause{{Terms: []string{"http", "handler"}}},
+		},
+		{
+			name:  "phrase",
+			input: `"hello world" go`,
+			want:  []Clause{{Terms: []string{"hello", "world"}}, {Terms: []string{"go"}}},
+		},
+		{
+			name:  "unterminated phrase",
+			input: `"hello world`,
+			want:  []Clause{{Terms: []string{"hello", "world"}}},
+		},
+		{
+			name:  "field",
+			input: "title:readme",
+			want:  []Clause{{Field: FieldTitle, Terms: []string{"readme"}}},
+		},
+		{
+			name:  "field is case insensitive",
+			input: "Description:Notes",
+			want:  []Clause{{Field: FieldDescription, Terms: []string{"notes"}}},
+		},
+		{
+			name:  "field with phrase",
+			input: `content:"func main" title:x`,
+			want: []Clause{
+				{Field: FieldContent, Terms: []string{"func", "main"}},
+				{Field: FieldTitle, Terms: []string{"x"}},
+			},
+		},
+		{
+			name:  "field after a word",
+			input: "go title:readme",
+			want:  []Clause{{Terms: []string{"go"}}, {Field: FieldTitle, Terms: []string{"readme"}}},
+		},
+		{
+			name:  "unknown field",
+			input: "author:alice",
+			want:  []Clause{{Terms: []string{"author", "alice"}}},
+		},
+		{
+			name:  "url",
+			input: "https://example.com",
+			want:  []Clause{{Terms: []string{"https", "example", "com"}}},
+		},
+		{"field without text", "title:", nil},
+		{"field with empty phrase", `title:""`, nil},
+		{
+			name:  "unicode",
+			input: "Größe 日本語",
+			want:  []Clause{{Terms: []string{"größe"}}, {Terms: []string{"日本語"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseQuery(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
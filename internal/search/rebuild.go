@@ -0,0 +1,31 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+// rebuildTimeout bounds loading the corpus at startup, so that a slow or
+// stuck read does not keep every replica busy indefinitely.
+const rebuildTimeout = 10 * time.Minute
+
+// Rebuild indexes every gist in the repository and returns how many were
+// indexed. It gives up after rebuildTimeout, leaving the gists indexed so
+// far searchable.
+func Rebuild(ctx context.Context, idx Index, repo repository.GistRepository) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, rebuildTimeout)
+	defer cancel()
+
+	count := 0
+	err := repo.Walk(ctx, func(gist *model.Gist) error {
+		if err := idx.Index(gist); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
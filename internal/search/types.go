@@ -0,0 +1,62 @@
+package search
+
+import (
+	"sync"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+// Searchable fields.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldContent     = "content"
+)
+
+// Index defines the interface for full-text gist search.
+type Index interface {
+	Index(gist *model.Gist) error
+	Delete(id string) error
+	Search(query Query) ([]Hit, error)
+}
+
+// Query describes a search request. All clauses must match.
+type Query struct {
+	Clauses  []Clause
+	Language string
+	From     time.Time
+	To       time.Time
+	CallerID string
+	Limit    int
+}
+
+// Clause matches a term or phrase, optionally restricted to one field.
+// Multiple terms must appear consecutively.
+type Clause struct {
+	Field string
+	Terms []string
+}
+
+// Hit is a single search result.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// MemoryIndex implements Index with an in-process inverted index.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*document
+	postings map[string]map[string]struct{}
+}
+
+type document struct {
+	id         string
+	userID     string
+	language   string
+	visibility model.Visibility
+	isDraft    bool
+	createdAt  time.Time
+	positions  map[string]map[string][]int
+}
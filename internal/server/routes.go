@@ -3,25 +3,80 @@ package server
 import (
 	"net/http"
 
-	"github.com/julienschmidt/httprouter"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abhisheksharm-3/quickgist/internal/middleware"
 )
 
 func (s *Server) routes() http.Handler {
-	router := httprouter.New()
+	router := chi.NewRouter()
 
-	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 	})
 
-	router.HandlerFunc(http.MethodGet, "/", s.handler.Home)
-	router.HandlerFunc(http.MethodGet, "/health", s.handler.Health)
-	router.HandlerFunc(http.MethodHead, "/health", s.handler.Health)
+	router.Use(s.handler.Authenticate)
+
+	router.Get("/", s.handler.Home)
+	router.Get("/health", s.handler.Health)
+	router.Head("/health", s.handler.Health)
+
+	router.Get("/gist/view/{id}", s.handler.View)
+	router.Get("/gist/{id}/rendered", s.handler.Rendered)
+	router.Get("/gist/{id}.js", s.handler.EmbedScript)
+	router.Get("/gist/{id}/archive.zip", s.handler.ArchiveZip)
+	router.Get("/gist/{id}/archive.tar.gz", s.handler.ArchiveTarGz)
+	router.Get("/gist/{id}.git/info/refs", s.handler.GitInfoRefs)
+	router.Post("/gist/{id}.git/git-upload-pack", s.handler.GitUploadPack)
+	router.Post("/gist/{id}.git/git-receive-pack", s.handler.GitReceivePack)
+	router.With(middleware.Embeddable()).Get("/gist/{id}/embed", s.handler.Embed)
+	router.Post("/gist/create", s.handler.Create)
+	router.Put("/gist/{id}", s.handler.Update)
+	router.Delete("/gist/{id}", s.handler.Delete)
+	router.Put("/gist/{id}/star", s.handler.Star)
+	router.Delete("/gist/{id}/star", s.handler.Unstar)
+	router.Get("/gist/{id}/comments", s.handler.ListComments)
+	router.Post("/gist/{id}/comments", s.handler.CreateComment)
+	router.Patch("/gist/{id}/comments/{commentId}", s.handler.UpdateComment)
+	router.Delete("/gist/{id}/comments/{commentId}", s.handler.DeleteComment)
+	router.Get("/gist/user-gists", s.handler.ListByUser)
+	router.Get("/gist/search", s.handler.Search)
+
+	router.Get("/api/gh/gists", s.handler.GitHubListGists)
+	router.Post("/api/gh/gists", s.handler.GitHubCreateGist)
+	router.Get("/api/gh/gists/{id}", s.handler.GitHubGetGist)
+	router.Patch("/api/gh/gists/{id}", s.handler.GitHubUpdateGist)
+	router.Delete("/api/gh/gists/{id}", s.handler.GitHubDeleteGist)
+	router.Get("/api/gh/gists/{id}/star", s.handler.GitHubStarStatus)
+	router.Put("/api/gh/gists/{id}/star", s.handler.GitHubStar)
+	router.Delete("/api/gh/gists/{id}/star", s.handler.GitHubUnstar)
+	router.Get("/api/gh/gists/{id}/raw/{file}", s.handler.GitHubRaw)
+	router.Get("/api/gh/users/{userId}/gists", s.handler.GitHubUserGists)
+
+	router.Get("/u/{userId}/feed.atom", s.handler.AtomFeed)
+	router.Get("/u/{userId}/feed.json", s.handler.JSONFeed)
+
+	router.Get("/me/tags", s.handler.UserTags)
+	router.Get("/me/stars", s.handler.ListStars)
+	router.Post("/me/export", s.handler.CreateExport)
+	router.Get("/me/exports", s.handler.ListExports)
+	router.Get("/me/exports/{exportId}", s.handler.GetExport)
+	router.Get("/me/exports/{exportId}/download", s.handler.DownloadExport)
+	router.Get("/me/deletion", s.handler.PreviewAccountDeletion)
+	router.Delete("/me", s.handler.DeleteAccount)
 
-	router.HandlerFunc(http.MethodGet, "/gist/view/:id", s.handler.View)
-	router.HandlerFunc(http.MethodPost, "/gist/create", s.handler.Create)
-	router.HandlerFunc(http.MethodGet, "/gist/user-gists", s.handler.ListByUser)
+	router.Get("/me/tokens", s.handler.ListTokens)
+	router.Post("/me/tokens", s.handler.CreateToken)
+	router.Delete("/me/tokens/{id}", s.handler.DeleteToken)
+	router.Get("/me/webhooks", s.handler.ListWebhooks)
+	router.Post("/me/webhooks", s.handler.CreateWebhook)
+	router.Delete("/me/webhooks/{id}", s.handler.DeleteWebhook)
+	router.Get("/me/webhooks/{id}/deliveries", s.handler.ListDeliveries)
+	router.Post("/me/webhooks/{id}/deliveries/{deliveryId}/redeliver", s.handler.Redeliver)
 
-	router.HandlerFunc(http.MethodGet, "/files/:snippetId/*filepath", s.handler.ServeFile)
+	router.Get("/oembed", s.handler.OEmbed)
+	router.Get("/assets/embed.css", s.handler.EmbedStylesheet)
+	router.Get("/files/{snippetId}/*", s.handler.ServeFile)
 
 	return router
 }
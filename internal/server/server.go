@@ -89,10 +89,15 @@ func (s *Server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
 	defer cancel()
 
-	if err := s.httpServer.Shutdown(ctx); err != nil {
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
 		s.errorLog.Printf("error during shutdown: %v", err)
-		return s.httpServer.Close()
+		err = s.httpServer.Close()
 	}
 
-	return nil
+	if closeErr := s.handler.Close(ctx); closeErr != nil {
+		s.errorLog.Printf("error flushing handler state: %v", closeErr)
+	}
+
+	return err
 }
@@ -0,0 +1,115 @@
+package stats
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+const flushTimeout = 30 * time.Second
+
+// NewAggregator creates an Aggregator that flushes to sink every interval.
+func NewAggregator(sink Sink, interval time.Duration, errorLog *log.Logger) *Aggregator {
+	a := &Aggregator{
+		sink:     sink,
+		interval: interval,
+		errorLog: errorLog,
+		pending:  make(map[string]model.GistCounts),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go a.run()
+	return a
+}
+
+// RecordView counts a view of a gist.
+func (a *Aggregator) RecordView(gistID string) {
+	a.add(gistID, model.GistCounts{Views: 1})
+}
+
+// RecordDownload counts a download of a gist attachment.
+func (a *Aggregator) RecordDownload(gistID string) {
+	a.add(gistID, model.GistCounts{Downloads: 1})
+}
+
+// Pending returns the counts of a gist that have not been persisted yet.
+func (a *Aggregator) Pending(gistID string) model.GistCounts {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pending := a.pending[gistID]
+	flushing := a.flushing[gistID]
+	return model.GistCounts{
+		Views:     pending.Views + flushing.Views,
+		Downloads: pending.Downloads + flushing.Downloads,
+	}
+}
+
+// Flush writes all pending counts to the sink. Counters are best effort: a
+// failed batch is dropped rather than retried, since a partially applied
+// batch cannot be retried without counting twice.
+func (a *Aggregator) Flush(ctx context.Context) error {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	a.mu.Lock()
+	if len(a.pending) == 0 {
+		a.mu.Unlock()
+		return nil
+	}
+	batch := a.pending
+	a.pending = make(map[string]model.GistCounts)
+	a.flushing = batch
+	a.mu.Unlock()
+
+	err := a.sink.IncrementCounts(ctx, batch)
+
+	a.mu.Lock()
+	a.flushing = nil
+	a.mu.Unlock()
+
+	return err
+}
+
+// Close stops periodic flushing and writes the remaining counts.
+func (a *Aggregator) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() {
+		close(a.stop)
+	})
+	<-a.done
+
+	return a.Flush(ctx)
+}
+
+func (a *Aggregator) add(gistID string, delta model.GistCounts) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	counts := a.pending[gistID]
+	counts.Views += delta.Views
+	counts.Downloads += delta.Downloads
+	a.pending[gistID] = counts
+}
+
+func (a *Aggregator) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			if err := a.Flush(ctx); err != nil {
+				a.errorLog.Printf("failed to flush counters: %v", err)
+			}
+			cancel()
+		case <-a.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+)
+
+// Sink persists batched counter increments.
+type Sink interface {
+	IncrementCounts(ctx context.Context, deltas map[string]model.GistCounts) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, deltas map[string]model.GistCounts) error
+
+// IncrementCounts calls f(ctx, deltas).
+func (f SinkFunc) IncrementCounts(ctx context.Context, deltas map[string]model.GistCounts) error {
+	return f(ctx, deltas)
+}
+
+// Aggregator collects gist view and download counts in memory and writes
+// them to a Sink in batches.
+type Aggregator struct {
+	sink     Sink
+	interval time.Duration
+	errorLog *log.Logger
+
+	mu       sync.Mutex
+	pending  map[string]model.GistCounts
+	flushing map[string]model.GistCounts
+	flushMu  sync.Mutex
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
@@ -2,19 +2,25 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
 	"time"
 
+	gcs "cloud.google.com/go/storage"
 	firebase "firebase.google.com/go"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
 	"github.com/abhisheksharm-3/quickgist/internal/apperror"
 )
 
 const (
-	uploadTimeout   = 30 * time.Second
+	// uploadTimeout covers reading the content, which may be streamed
+	// from a client.
+	uploadTimeout   = 5 * time.Minute
 	filePathPrefix  = "quickgist-user-files"
 	fileURLPattern  = "/files/%s/%s"
 )
@@ -48,21 +54,18 @@ func (s *FirebaseStorage) Upload(ctx context.Context, gistID, filename string, c
 	ctx, cancel := context.WithTimeout(ctx, uploadTimeout)
 	defer cancel()
 
-	client, err := s.app.Storage(ctx)
-	if err != nil {
-		return nil, apperror.Storage(err)
-	}
-
-	bucket, err := client.DefaultBucket()
+	bucket, err := s.bucket(ctx)
 	if err != nil {
-		return nil, apperror.Storage(err)
+		return nil, err
 	}
 
-	objectPath := fmt.Sprintf("%s/%s/%s", filePathPrefix, gistID, filename)
-	obj := bucket.Object(objectPath)
+	objectName := objectPath(gistID, filename)
+	obj := bucket.Object(objectName)
 	writer := obj.NewWriter(ctx)
 
 	if _, err := io.Copy(writer, content); err != nil {
+		// Cancelling before closing discards the partial object.
+		cancel()
 		writer.Close()
 		return nil, apperror.Storage(err)
 	}
@@ -76,19 +79,137 @@ func (s *FirebaseStorage) Upload(ctx context.Context, gistID, filename string, c
 		return nil, apperror.Storage(err)
 	}
 
+	return s.fileInfo(gistID, filename, attrs), nil
+}
+
+// Move copies a stored file to a new name within the bucket and deletes
+// the original. A failure to delete the original is ignored since the
+// file is already in place.
+func (s *FirebaseStorage) Move(ctx context.Context, fromGistID, fromFilename, gistID, filename string) (*FileInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, uploadTimeout)
+	defer cancel()
+
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	src := bucket.Object(objectPath(fromGistID, fromFilename))
+	attrs, err := bucket.Object(objectPath(gistID, filename)).CopierFrom(src).Run(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, apperror.NotFound("file")
+		}
+		return nil, apperror.Storage(err)
+	}
+
+	_ = src.Delete(ctx)
+	return s.fileInfo(gistID, filename, attrs), nil
+}
+
+// Open returns a reader for a stored file along with its metadata. The
+// caller must close the reader.
+func (s *FirebaseStorage) Open(ctx context.Context, gistID, filename string) (io.ReadCloser, *FileInfo, error) {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := bucket.Object(objectPath(gistID, filename)).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, nil, apperror.NotFound("file")
+		}
+		return nil, nil, apperror.Storage(err)
+	}
+
+	return reader, &FileInfo{
+		FileName: filename,
+		Size:     reader.Attrs.Size,
+		Updated:  reader.Attrs.LastModified,
+	}, nil
+}
+
+// Delete removes a stored file. Deleting a missing file is not an error.
+func (s *FirebaseStorage) Delete(ctx context.Context, gistID, filename string) error {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = bucket.Object(objectPath(gistID, filename)).Delete(ctx)
+	if err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return apperror.Storage(err)
+	}
+
+	return nil
+}
+
+// Walk calls fn for every stored file, stopping at the first error.
+func (s *FirebaseStorage) Walk(ctx context.Context, fn func(gistID string, info *FileInfo) error) error {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	iter := bucket.Objects(ctx, &gcs.Query{Prefix: filePathPrefix + "/"})
+	for {
+		attrs, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return apperror.Storage(err)
+		}
+
+		rest := strings.TrimPrefix(attrs.Name, filePathPrefix+"/")
+		gistID, filename, ok := strings.Cut(rest, "/")
+		if !ok || gistID == "" || filename == "" {
+			continue
+		}
+
+		info := &FileInfo{
+			FileName: filename,
+			Size:     attrs.Size,
+			Updated:  attrs.Updated,
+		}
+		if err := fn(gistID, info); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *FirebaseStorage) fileInfo(gistID, filename string, attrs *gcs.ObjectAttrs) *FileInfo {
 	fileURL := fmt.Sprintf(
 		"https://firebasestorage.googleapis.com/v0/b/%s/o/%s?generation=%d&alt=media",
 		s.bucketName,
-		url.QueryEscape(objectPath),
+		url.QueryEscape(attrs.Name),
 		attrs.Generation,
 	)
 
-	publicFileURL := fmt.Sprintf(fileURLPattern, gistID, url.PathEscape(filename))
-
 	return &FileInfo{
 		FileName:      filename,
 		FileURL:       fileURL,
-		PublicFileURL: publicFileURL,
+		PublicFileURL: fmt.Sprintf(fileURLPattern, gistID, url.PathEscape(filename)),
 		Size:          attrs.Size,
-	}, nil
+		Updated:       attrs.Updated,
+	}
+}
+
+func (s *FirebaseStorage) bucket(ctx context.Context) (*gcs.BucketHandle, error) {
+	client, err := s.app.Storage(ctx)
+	if err != nil {
+		return nil, apperror.Storage(err)
+	}
+
+	bucket, err := client.DefaultBucket()
+	if err != nil {
+		return nil, apperror.Storage(err)
+	}
+
+	return bucket, nil
+}
+
+func objectPath(gistID, filename string) string {
+	return fmt.Sprintf("%s/%s/%s", filePathPrefix, gistID, filename)
 }
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // FileInfo contains metadata about an uploaded file.
@@ -11,9 +12,23 @@ type FileInfo struct {
 	FileURL       string
 	PublicFileURL string
 	Size          int64
+	Updated       time.Time
 }
 
-// FileStorage defines the interface for file storage operations.
+// FileStorage defines the interface for file storage operations. Upload
+// takes the size of content, or -1 if it is not known in advance. Move
+// stores a file under a new name and removes the original, so that a file
+// uploaded in full elsewhere appears under its final name at once.
 type FileStorage interface {
 	Upload(ctx context.Context, gistID, filename string, content io.Reader, size int64) (*FileInfo, error)
+	Open(ctx context.Context, gistID, filename string) (io.ReadCloser, *FileInfo, error)
+	Move(ctx context.Context, fromGistID, fromFilename, gistID, filename string) (*FileInfo, error)
+	Delete(ctx context.Context, gistID, filename string) error
+}
+
+// Lister is implemented by storages that can enumerate their files. Walk
+// calls fn for every stored file with the ID of the gist it belongs to,
+// stopping at the first error.
+type Lister interface {
+	Walk(ctx context.Context, fn func(gistID string, info *FileInfo) error) error
 }
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/config"
+)
+
+var errPrivateTarget = errors.New("webhook target resolves to a private address")
+
+// newClient returns the HTTP client used for deliveries. Unless private
+// targets are allowed, it refuses to connect to loopback, private and
+// link-local addresses so that webhooks cannot probe internal services.
+// The check runs after DNS resolution, which also covers redirects.
+func newClient(cfg config.WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateTargets {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublic(addrPort.Addr()) {
+				return errPrivateTarget
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: cfg.Timeout,
+		},
+	}
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast()
+}
@@ -0,0 +1,272 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/abhisheksharm-3/quickgist/internal/apperror"
+	"github.com/abhisheksharm-3/quickgist/internal/config"
+	"github.com/abhisheksharm-3/quickgist/internal/model"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+const (
+	claimBatchSize   = 20
+	baseBackoff      = 30 * time.Second
+	maxBackoff       = 6 * time.Hour
+	maxErrorLength   = 512
+	userAgent        = "QuickGist-Hookshot"
+	operationTimeout = 30 * time.Second
+)
+
+// NewDispatcher creates a Dispatcher and starts its delivery worker.
+func NewDispatcher(repo repository.WebhookRepository, cfg config.WebhookConfig, errorLog *log.Logger) *Dispatcher {
+	d := &Dispatcher{
+		repo:     repo,
+		config:   cfg,
+		client:   newClient(cfg),
+		errorLog: errorLog,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go d.run()
+	return d
+}
+
+// Enqueue queues a delivery of payload to every webhook of the user that
+// subscribes to the event.
+func (d *Dispatcher) Enqueue(ctx context.Context, userID string, event model.WebhookEvent, payload interface{}) error {
+	webhooks, err := d.repo.ListWebhooks(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+
+		if body == nil {
+			if body, err = json.Marshal(payload); err != nil {
+				return apperror.Internal(err)
+			}
+		}
+
+		delivery := model.NewWebhookDelivery(webhook, event, string(body))
+		if _, err := d.repo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	if body != nil {
+		d.notify()
+	}
+	return nil
+}
+
+// Redeliver queues a new delivery of a previous delivery's payload.
+func (d *Dispatcher) Redeliver(ctx context.Context, previous *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{
+		WebhookID:     previous.WebhookID,
+		UserID:        previous.UserID,
+		Event:         previous.Event,
+		Payload:       previous.Payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: time.Now().UTC(),
+		RedeliveryOf:  previous.ID,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	id, err := d.repo.CreateDelivery(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	delivery.ID = id
+
+	d.notify()
+	return delivery, nil
+}
+
+// Close stops the delivery worker after its current batch. Queued
+// deliveries stay in the repository and are sent after the next start.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.closeOnce.Do(func() {
+		close(d.stop)
+	})
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sign returns the signature header value for a payload: the hex encoded
+// HMAC-SHA256 of the body keyed with the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		case <-d.stop:
+			return
+		}
+
+		d.processDue()
+	}
+}
+
+// processDue sends due deliveries until none are left or the dispatcher is
+// closed.
+func (d *Dispatcher) processDue() {
+	// The lease must outlast a whole batch of attempts so that another
+	// instance does not pick up deliveries that are still in flight.
+	lease := claimBatchSize*d.config.Timeout + time.Minute
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+		deliveries, err := d.repo.ClaimDueDeliveries(ctx, time.Now().UTC(), lease, claimBatchSize)
+		cancel()
+		if err != nil {
+			d.errorLog.Printf("failed to claim webhook deliveries: %v", err)
+			return
+		}
+
+		for _, delivery := range deliveries {
+			d.attempt(delivery)
+		}
+
+		if len(deliveries) < claimBatchSize {
+			return
+		}
+	}
+}
+
+// attempt sends a delivery once and records the outcome.
+func (d *Dispatcher) attempt(delivery *model.WebhookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+	delivery.ResponseStatus = 0
+	delivery.Error = ""
+
+	webhook, err := d.repo.GetWebhook(ctx, delivery.WebhookID)
+	switch {
+	case apperror.Is(err, apperror.CodeNotFound):
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = "webhook was deleted"
+	case err != nil:
+		delivery.Error = err.Error()
+		d.reschedule(delivery, now)
+	default:
+		status, err := d.send(ctx, webhook, delivery)
+		delivery.ResponseStatus = status
+		if err == nil {
+			delivery.Status = model.DeliverySucceeded
+		} else {
+			delivery.Error = truncate(err.Error(), maxErrorLength)
+			d.reschedule(delivery, now)
+		}
+	}
+
+	// A delivery deleted with its owner's account is not recorded again.
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil && !apperror.Is(err, apperror.CodeNotFound) {
+		d.errorLog.Printf("failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// reschedule schedules the next attempt of a failed delivery, giving up
+// after the configured number of attempts.
+func (d *Dispatcher) reschedule(delivery *model.WebhookDelivery, now time.Time) {
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = model.DeliveryFailed
+		return
+	}
+
+	delivery.Status = model.DeliveryPending
+	delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts))
+}
+
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt, doubling with every
+// attempt and jittered by up to 10% so that retries do not align.
+func backoff(attempts int) time.Duration {
+	delay := maxBackoff
+	if attempts < 20 {
+		delay = min(baseBackoff<<(attempts-1), maxBackoff)
+	}
+	return delay + rand.N(delay/10+1)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
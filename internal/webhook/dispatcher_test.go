@@ -0,0 +1,46 @@
+package webhook
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   string
+	}{
+		{
+			name:   "rfc 4231 test case 2",
+			secret: "Jefe",
+			body:   "what do ya want for nothing?",
+			want:   "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			name:   "empty secret and body",
+			secret: "",
+			body:   "",
+			want:   "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad",
+		},
+		{
+			name:   "json payload",
+			secret: "s3cret",
+			body:   `{"zen":"hi"}`,
+			want:   "sha256=b18ce78d18646301e6811d72dbafbde385e5ab0f65482fdb261485ca0773c8bc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignDependsOnSecret(t *testing.T) {
+	body := []byte(`{"zen":"hi"}`)
+	if Sign("a", body) == Sign("b", body) {
+		t.Error("signatures with different secrets must differ")
+	}
+}
@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/abhisheksharm-3/quickgist/internal/config"
+	"github.com/abhisheksharm-3/quickgist/internal/repository"
+)
+
+// Request headers sent with every delivery.
+const (
+	HeaderEvent     = "X-QuickGist-Event"
+	HeaderDelivery  = "X-QuickGist-Delivery"
+	HeaderSignature = "X-QuickGist-Signature-256"
+)
+
+// Dispatcher queues webhook deliveries in the repository and sends them
+// from a background worker, retrying failures with exponential backoff.
+type Dispatcher struct {
+	repo     repository.WebhookRepository
+	config   config.WebhookConfig
+	client   *http.Client
+	errorLog *log.Logger
+
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}